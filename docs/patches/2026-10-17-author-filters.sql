@@ -0,0 +1,4 @@
+ALTER TABLE "messages" ADD COLUMN "isBot" bool NOT NULL DEFAULT false;
+
+ALTER TABLE "chatSettings" ADD COLUMN "excludeBots" bool NOT NULL DEFAULT false;
+ALTER TABLE "chatSettings" ADD COLUMN "excludeAnonymous" bool NOT NULL DEFAULT false;
@@ -0,0 +1,13 @@
+CREATE TABLE "chatSettings" (
+	"chatId" int8 NOT NULL,
+	"topN" int4 NOT NULL DEFAULT 10,
+	"period" varchar(16) NOT NULL,
+	"sort" varchar(16) NOT NULL,
+	"language" varchar(8),
+	"timezone" varchar(64) NOT NULL,
+	"excludedUserIds" int8[],
+	"excludedThreadIds" int4[],
+	"createdAt" timestamp with time zone NOT NULL DEFAULT now(),
+	"updatedAt" timestamp with time zone NOT NULL DEFAULT now(),
+	PRIMARY KEY("chatId")
+);
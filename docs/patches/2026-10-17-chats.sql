@@ -0,0 +1,17 @@
+CREATE TABLE "chats" (
+	"chatId" int8 NOT NULL,
+	"type" varchar(16) NOT NULL,
+	"title" varchar(255),
+	"username" varchar(64),
+	"isForum" bool NOT NULL DEFAULT false,
+	"memberStatus" varchar(16) NOT NULL,
+	"adminRights" jsonb,
+	"joinedAt" timestamp with time zone,
+	"leftAt" timestamp with time zone,
+	"cleanupAt" timestamp with time zone,
+	"createdAt" timestamp with time zone NOT NULL DEFAULT now(),
+	"updatedAt" timestamp with time zone NOT NULL DEFAULT now(),
+	PRIMARY KEY("chatId")
+);
+
+CREATE INDEX "IX_chats_cleanupAt" ON "chats" ("cleanupAt") WHERE "cleanupAt" IS NOT NULL;
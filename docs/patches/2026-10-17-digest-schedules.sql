@@ -0,0 +1,18 @@
+CREATE TABLE "digestSchedules" (
+	"digestScheduleId" serial NOT NULL,
+	"chatId" int8 NOT NULL,
+	"threadId" int4,
+	"cron" varchar(64) NOT NULL,
+	"timezone" varchar(64) NOT NULL,
+	"period" varchar(16) NOT NULL,
+	"sort" varchar(16) NOT NULL,
+	"quietFrom" int4,
+	"quietTo" int4,
+	"nextRunAt" timestamp with time zone NOT NULL,
+	"lastRunAt" timestamp with time zone,
+	"createdAt" timestamp with time zone NOT NULL DEFAULT now(),
+	PRIMARY KEY("digestScheduleId")
+);
+
+CREATE INDEX "IX_FK_digestSchedules_chatId" ON "digestSchedules" ("chatId");
+CREATE INDEX "IX_digestSchedules_nextRunAt" ON "digestSchedules" ("nextRunAt");
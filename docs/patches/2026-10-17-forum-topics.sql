@@ -0,0 +1,8 @@
+CREATE TABLE "forumTopics" (
+	"chatId" int8 NOT NULL,
+	"threadId" int4 NOT NULL,
+	"title" varchar(128) NOT NULL,
+	"createdAt" timestamp with time zone NOT NULL DEFAULT now(),
+	"updatedAt" timestamp with time zone NOT NULL DEFAULT now(),
+	PRIMARY KEY("chatId","threadId")
+);
@@ -0,0 +1,15 @@
+CREATE TABLE "messages" (
+	"chatId" int8 NOT NULL,
+	"messageId" int8 NOT NULL,
+	"threadId" int4,
+	"userId" int8,
+	"senderChatId" int8,
+	"authorName" varchar(255),
+	"mediaKind" varchar(32),
+	"text" text,
+	"sentAt" timestamp with time zone NOT NULL,
+	"createdAt" timestamp with time zone NOT NULL DEFAULT now(),
+	PRIMARY KEY("chatId","messageId")
+);
+
+CREATE INDEX "IX_messages_chatId_sentAt" ON "messages" ("chatId","sentAt");
@@ -0,0 +1,14 @@
+CREATE TABLE "reactionBuckets" (
+	"chatId" int8 NOT NULL,
+	"messageId" int8 NOT NULL,
+	"bucketAt" timestamp with time zone NOT NULL,
+	"reactionsCount" int4 NOT NULL DEFAULT 0,
+	PRIMARY KEY("chatId","messageId","bucketAt")
+);
+
+CREATE INDEX "IX_reactionBuckets_chatId_bucketAt" ON "reactionBuckets" ("chatId","bucketAt");
+
+INSERT INTO "reactionBuckets" ("chatId", "messageId", "bucketAt", "reactionsCount")
+SELECT "chatId", "messageId", date_trunc('hour', "createdAt"), sum(CASE WHEN "isAdded" THEN 1 ELSE -1 END)
+FROM "reactionEvents"
+GROUP BY 1, 2, 3;
@@ -0,0 +1 @@
+ALTER TABLE "messageReactions" ADD COLUMN "countsSyncedAt" timestamp with time zone;
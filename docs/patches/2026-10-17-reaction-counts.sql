@@ -0,0 +1,9 @@
+CREATE TABLE "messageReactionCounts" (
+	"chatId" int8 NOT NULL,
+	"messageId" int8 NOT NULL,
+	"reactionType" varchar(16) NOT NULL,
+	"reaction" varchar(64) NOT NULL,
+	"count" int4 NOT NULL DEFAULT 0,
+	"updatedAt" timestamp with time zone NOT NULL DEFAULT now(),
+	PRIMARY KEY("chatId","messageId","reactionType","reaction")
+);
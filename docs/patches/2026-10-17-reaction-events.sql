@@ -0,0 +1,17 @@
+ALTER TABLE "messageReactions" ADD COLUMN "reactorsCount" int4 NOT NULL DEFAULT 0;
+
+CREATE TABLE "reactionEvents" (
+	"reactionEventId" bigserial NOT NULL,
+	"chatId" int8 NOT NULL,
+	"messageId" int8 NOT NULL,
+	"userId" int8,
+	"actorChatId" int8,
+	"reactionType" varchar(16) NOT NULL,
+	"reaction" varchar(64) NOT NULL,
+	"isAdded" bool NOT NULL,
+	"createdAt" timestamp with time zone NOT NULL DEFAULT now(),
+	PRIMARY KEY("reactionEventId")
+);
+
+CREATE INDEX "IX_FK_reactionEvents_chatId_messageId" ON "reactionEvents" ("chatId","messageId");
+CREATE INDEX "IX_reactionEvents_chatId_createdAt" ON "reactionEvents" ("chatId","createdAt");
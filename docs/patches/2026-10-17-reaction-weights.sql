@@ -0,0 +1,15 @@
+ALTER TABLE "messageReactions" ADD COLUMN "score" float8 NOT NULL DEFAULT 0;
+
+CREATE TABLE "reactionWeights" (
+	"chatId" int8 NOT NULL,
+	"reactionType" varchar(16) NOT NULL,
+	"reaction" varchar(64) NOT NULL,
+	"weight" float8 NOT NULL,
+	"createdAt" timestamp with time zone NOT NULL DEFAULT now(),
+	"updatedAt" timestamp with time zone NOT NULL DEFAULT now(),
+	PRIMARY KEY("chatId","reactionType","reaction")
+);
+
+UPDATE "messageReactions" AS t SET "score" = coalesce((
+	SELECT sum(c."count") FROM "messageReactionCounts" c WHERE c."chatId" = t."chatId" AND c."messageId" = t."messageId"
+), 0);
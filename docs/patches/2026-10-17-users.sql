@@ -0,0 +1,9 @@
+CREATE TABLE "users" (
+	"userId" int8 NOT NULL,
+	"name" varchar(255) NOT NULL,
+	"username" varchar(64),
+	"optedOutAt" timestamp with time zone,
+	"createdAt" timestamp with time zone NOT NULL DEFAULT now(),
+	"updatedAt" timestamp with time zone NOT NULL DEFAULT now(),
+	PRIMARY KEY("userId")
+);
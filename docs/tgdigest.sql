@@ -8,9 +8,147 @@ CREATE TABLE "messageReactions" (
 	"messageId" int8 NOT NULL,
 	"chatId" int8 NOT NULL,
 	"reactionsCount" int4 NOT NULL DEFAULT 0,
+	"reactorsCount" int4 NOT NULL DEFAULT 0,
+	"countsSyncedAt" timestamp with time zone,
+	"score" float8 NOT NULL DEFAULT 0,
 	"createdAt" timestamp with time zone NOT NULL DEFAULT now(),
 	PRIMARY KEY("chatId","messageId")
 );
 
+CREATE TABLE "messageReactionCounts" (
+	"chatId" int8 NOT NULL,
+	"messageId" int8 NOT NULL,
+	"reactionType" varchar(16) NOT NULL,
+	"reaction" varchar(64) NOT NULL,
+	"count" int4 NOT NULL DEFAULT 0,
+	"updatedAt" timestamp with time zone NOT NULL DEFAULT now(),
+	PRIMARY KEY("chatId","messageId","reactionType","reaction")
+);
+
+CREATE TABLE "reactionEvents" (
+	"reactionEventId" bigserial NOT NULL,
+	"chatId" int8 NOT NULL,
+	"messageId" int8 NOT NULL,
+	"userId" int8,
+	"actorChatId" int8,
+	"reactionType" varchar(16) NOT NULL,
+	"reaction" varchar(64) NOT NULL,
+	"isAdded" bool NOT NULL,
+	"createdAt" timestamp with time zone NOT NULL DEFAULT now(),
+	PRIMARY KEY("reactionEventId")
+);
+
+CREATE INDEX "IX_FK_reactionEvents_chatId_messageId" ON "reactionEvents" ("chatId","messageId");
+CREATE INDEX "IX_reactionEvents_chatId_createdAt" ON "reactionEvents" ("chatId","createdAt");
+
+CREATE TABLE "messages" (
+	"chatId" int8 NOT NULL,
+	"messageId" int8 NOT NULL,
+	"threadId" int4,
+	"userId" int8,
+	"senderChatId" int8,
+	"isBot" bool NOT NULL DEFAULT false,
+	"authorName" varchar(255),
+	"mediaKind" varchar(32),
+	"text" text,
+	"sentAt" timestamp with time zone NOT NULL,
+	"createdAt" timestamp with time zone NOT NULL DEFAULT now(),
+	PRIMARY KEY("chatId","messageId")
+);
+
+CREATE INDEX "IX_messages_chatId_sentAt" ON "messages" ("chatId","sentAt");
+
+CREATE TABLE "forumTopics" (
+	"chatId" int8 NOT NULL,
+	"threadId" int4 NOT NULL,
+	"title" varchar(128) NOT NULL,
+	"createdAt" timestamp with time zone NOT NULL DEFAULT now(),
+	"updatedAt" timestamp with time zone NOT NULL DEFAULT now(),
+	PRIMARY KEY("chatId","threadId")
+);
+
+CREATE TABLE "chats" (
+	"chatId" int8 NOT NULL,
+	"type" varchar(16) NOT NULL,
+	"title" varchar(255),
+	"username" varchar(64),
+	"isForum" bool NOT NULL DEFAULT false,
+	"memberStatus" varchar(16) NOT NULL,
+	"adminRights" jsonb,
+	"joinedAt" timestamp with time zone,
+	"leftAt" timestamp with time zone,
+	"cleanupAt" timestamp with time zone,
+	"createdAt" timestamp with time zone NOT NULL DEFAULT now(),
+	"updatedAt" timestamp with time zone NOT NULL DEFAULT now(),
+	PRIMARY KEY("chatId")
+);
+
+CREATE INDEX "IX_chats_cleanupAt" ON "chats" ("cleanupAt") WHERE "cleanupAt" IS NOT NULL;
+
+CREATE TABLE "reactionWeights" (
+	"chatId" int8 NOT NULL,
+	"reactionType" varchar(16) NOT NULL,
+	"reaction" varchar(64) NOT NULL,
+	"weight" float8 NOT NULL,
+	"createdAt" timestamp with time zone NOT NULL DEFAULT now(),
+	"updatedAt" timestamp with time zone NOT NULL DEFAULT now(),
+	PRIMARY KEY("chatId","reactionType","reaction")
+);
+
+CREATE TABLE "reactionBuckets" (
+	"chatId" int8 NOT NULL,
+	"messageId" int8 NOT NULL,
+	"bucketAt" timestamp with time zone NOT NULL,
+	"reactionsCount" int4 NOT NULL DEFAULT 0,
+	PRIMARY KEY("chatId","messageId","bucketAt")
+);
+
+CREATE INDEX "IX_reactionBuckets_chatId_bucketAt" ON "reactionBuckets" ("chatId","bucketAt");
+
+CREATE TABLE "digestSchedules" (
+	"digestScheduleId" serial NOT NULL,
+	"chatId" int8 NOT NULL,
+	"threadId" int4,
+	"cron" varchar(64) NOT NULL,
+	"timezone" varchar(64) NOT NULL,
+	"period" varchar(16) NOT NULL,
+	"sort" varchar(16) NOT NULL,
+	"quietFrom" int4,
+	"quietTo" int4,
+	"nextRunAt" timestamp with time zone NOT NULL,
+	"lastRunAt" timestamp with time zone,
+	"createdAt" timestamp with time zone NOT NULL DEFAULT now(),
+	PRIMARY KEY("digestScheduleId")
+);
+
+CREATE INDEX "IX_FK_digestSchedules_chatId" ON "digestSchedules" ("chatId");
+CREATE INDEX "IX_digestSchedules_nextRunAt" ON "digestSchedules" ("nextRunAt");
+
+CREATE TABLE "chatSettings" (
+	"chatId" int8 NOT NULL,
+	"topN" int4 NOT NULL DEFAULT 10,
+	"period" varchar(16) NOT NULL,
+	"sort" varchar(16) NOT NULL,
+	"language" varchar(8),
+	"timezone" varchar(64) NOT NULL,
+	"excludedUserIds" int8[],
+	"excludedThreadIds" int4[],
+	"excludeBots" bool NOT NULL DEFAULT false,
+	"excludeAnonymous" bool NOT NULL DEFAULT false,
+	"createdAt" timestamp with time zone NOT NULL DEFAULT now(),
+	"updatedAt" timestamp with time zone NOT NULL DEFAULT now(),
+	PRIMARY KEY("chatId")
+);
+
+CREATE TABLE "users" (
+	"userId" int8 NOT NULL,
+	"name" varchar(255) NOT NULL,
+	"username" varchar(64),
+	"optedOutAt" timestamp with time zone,
+	"createdAt" timestamp with time zone NOT NULL DEFAULT now(),
+	"updatedAt" timestamp with time zone NOT NULL DEFAULT now(),
+	PRIMARY KEY("userId")
+);
+
 
 
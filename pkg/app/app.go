@@ -34,8 +34,15 @@ type App struct {
 	echo    *echo.Echo
 	vtsrv   zenrpc.Server
 
-	b  *bot.Bot
-	bm *botsrv.BotManager
+	b      *bot.Bot
+	bm     *botsrv.BotManager
+	ra     *botsrv.ReactionAggregator
+	cancel context.CancelFunc
+	// botDone is closed when bot stops and all its handlers are finished.
+	botDone chan struct{}
+	// raCancel stops reaction aggregator after bot, raDone is closed after its final flush.
+	raCancel context.CancelFunc
+	raDone   chan struct{}
 }
 
 func New(appName string, verbose bool, cfg Config, db db.DB, dbc *pg.DB) *App {
@@ -51,11 +58,12 @@ func New(appName string, verbose bool, cfg Config, db db.DB, dbc *pg.DB) *App {
 	a.echo.HidePort = true
 	a.echo.IPExtractor = echo.ExtractIPFromRealIPHeader()
 
-	a.bm = botsrv.NewBotManager(a.Logger, a.db)
+	a.ra = botsrv.NewReactionAggregator(appName, a.Logger, a.db, cfg.Bot.AggregatorConfig)
+	a.bm = botsrv.NewBotManager(a.Logger, a.db, a.ra, cfg.Bot)
 
-	opts := []bot.Option{bot.WithAllowedUpdates(bot.AllowedUpdates{"message", "message_reaction", "message_reaction_count", "callback_query"}),
-		bot.WithDefaultHandler(a.bm.DefaultHandler)}
-	b, err := bot.New(cfg.Bot.Token, opts...)
+	opts := []bot.Option{bot.WithAllowedUpdates(bot.AllowedUpdates{"message", "channel_post", "message_reaction", "message_reaction_count", "callback_query",
+		"my_chat_member", "chat_member", "inline_query"})}
+	b, err := bot.New(cfg.Bot.Token, append(opts, a.bm.BotOptions()...)...)
 	if err != nil {
 		panic(err)
 	}
@@ -71,16 +79,47 @@ func (a *App) Run() error {
 	a.registerDebugHandlers()
 	a.registerAPIHandlers()
 
+	ctx, cancel := context.WithCancel(context.Background())
+	raCtx, raCancel := context.WithCancel(context.Background())
+	a.cancel, a.raCancel = cancel, raCancel
+	a.botDone, a.raDone = make(chan struct{}), make(chan struct{})
+
 	a.bm.RegisterBotHandlers(a.b)
-	go a.b.Start(context.TODO())
+	go func() {
+		defer close(a.raDone)
+		a.ra.Run(raCtx)
+	}()
+	go a.bm.RunChatCleanup(ctx)
+	go a.bm.RunDigestScheduler(ctx, a.b)
+	go func() {
+		defer close(a.botDone)
+		a.b.Start(ctx)
+	}()
 	return a.runHTTPServer(a.cfg.Server.Host, a.cfg.Server.Port)
 }
 
-// Shutdown is a function that gracefully stops HTTP server.
+// Shutdown is a function that gracefully stops bot and HTTP server. Buffered reactions are flushed to DB after
+// bot handlers are finished.
 func (a *App) Shutdown(timeout time.Duration) {
 	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
+	if a.cancel != nil {
+		a.cancel()
+		select {
+		case <-a.botDone:
+		case <-ctx.Done():
+			a.Errorf("stopping bot err=%q", ctx.Err())
+		}
+
+		a.raCancel()
+		select {
+		case <-a.raDone:
+		case <-ctx.Done():
+			a.Errorf("flushing reactions err=%q", ctx.Err())
+		}
+	}
+
 	if err := a.echo.Shutdown(ctx); err != nil {
 		a.Errorf("shutting down server err=%q", err)
 	}
@@ -30,6 +30,9 @@ func (a *App) registerMetrics() {
 	prometheus.MustRegister(metrics)
 	metrics.ObserveRegularly(context.Background(), a.dbc, "default")
 
+	// add reactions buffer metrics
+	prometheus.MustRegister(a.ra)
+
 	a.echo.Use(httpMetrics(a.appName))
 	a.echo.Any("/metrics", echo.WrapHandler(promhttp.Handler()))
 }
@@ -0,0 +1,409 @@
+package botsrv
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"botsrv/pkg/db"
+	"botsrv/pkg/embedlog"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	defaultFlushInterval = 2 * time.Second
+	defaultFlushSize     = 500
+)
+
+// AggregatorConfig sets reaction write buffer thresholds.
+type AggregatorConfig struct {
+	// FlushInterval is the max time reaction updates stay in memory.
+	FlushInterval time.Duration
+	// FlushSize is the number of buffered reaction updates that triggers flush.
+	FlushSize int
+}
+
+type messageKey struct {
+	ChatID    int64
+	MessageID int
+}
+
+// messageDelta is accumulated counters change of one message.
+type messageDelta struct {
+	reactions int
+	reactors  int
+	counts    map[reactionKey]int
+	// buckets is reactions change by hourly bucket it was received in.
+	buckets map[time.Time]int
+}
+
+// reactionSync is the latest authoritative reaction totals of one message.
+type reactionSync struct {
+	total    int
+	syncedAt time.Time
+	counts   []db.MessageReactionCount
+}
+
+// reactionBatch holds coalesced reaction updates. Deltas of message are always newer than its sync.
+type reactionBatch struct {
+	deltas  map[messageKey]*messageDelta
+	syncs   map[messageKey]reactionSync
+	events  []db.ReactionEvent
+	users   map[int64]db.User
+	updates int
+}
+
+func newReactionBatch() *reactionBatch {
+	return &reactionBatch{
+		deltas: make(map[messageKey]*messageDelta),
+		syncs:  make(map[messageKey]reactionSync),
+		users:  make(map[int64]db.User),
+	}
+}
+
+func (rb *reactionBatch) delta(mk messageKey) *messageDelta {
+	md, ok := rb.deltas[mk]
+	if !ok {
+		md = &messageDelta{counts: make(map[reactionKey]int), buckets: make(map[time.Time]int)}
+		rb.deltas[mk] = md
+	}
+
+	return md
+}
+
+// addDeltas adds user reaction change made at date.
+func (rb *reactionBatch) addDeltas(mk messageKey, deltas map[reactionKey]int, reactors int, date time.Time) {
+	md := rb.delta(mk)
+	md.reactors += reactors
+	for key, delta := range deltas {
+		md.reactions += delta
+		md.counts[key] += delta
+		md.buckets[db.ReactionBucketAt(date)] += delta
+	}
+}
+
+// addSync sets authoritative totals. Buffered reaction deltas of the message are dropped as totals include them,
+// reactors delta is kept because totals don't have it.
+func (rb *reactionBatch) addSync(mk messageKey, rs reactionSync) {
+	if prev, ok := rb.syncs[mk]; ok && prev.syncedAt.After(rs.syncedAt) {
+		return
+	}
+
+	rb.syncs[mk] = rs
+	if md, ok := rb.deltas[mk]; ok {
+		md.reactions = 0
+		md.counts = make(map[reactionKey]int)
+		md.buckets = make(map[time.Time]int)
+	}
+}
+
+// merge applies newer batch on top of rb.
+func (rb *reactionBatch) merge(newer *reactionBatch) {
+	for mk, rs := range newer.syncs {
+		rb.addSync(mk, rs)
+	}
+	for mk, md := range newer.deltas {
+		d := rb.delta(mk)
+		d.reactions += md.reactions
+		d.reactors += md.reactors
+		for key, delta := range md.counts {
+			d.counts[key] += delta
+		}
+		for bucketAt, delta := range md.buckets {
+			d.buckets[bucketAt] += delta
+		}
+	}
+	rb.events = append(rb.events, newer.events...)
+	for id, u := range newer.users {
+		rb.users[id] = u
+	}
+	rb.updates += newer.updates
+}
+
+// sortedUsers returns users of batch sorted by ID, so concurrent flushes lock rows in the same order.
+func (rb *reactionBatch) sortedUsers() []db.User {
+	users := make([]db.User, 0, len(rb.users))
+	for _, u := range rb.users {
+		users = append(users, u)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+
+	return users
+}
+
+// messageKeys returns sorted keys of messages in batch, so concurrent flushes lock rows in the same order.
+func (rb *reactionBatch) messageKeys() []messageKey {
+	keys := make([]messageKey, 0, len(rb.deltas)+len(rb.syncs))
+	for mk := range rb.syncs {
+		keys = append(keys, mk)
+	}
+	for mk := range rb.deltas {
+		if _, ok := rb.syncs[mk]; !ok {
+			keys = append(keys, mk)
+		}
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].ChatID != keys[j].ChatID {
+			return keys[i].ChatID < keys[j].ChatID
+		}
+		return keys[i].MessageID < keys[j].MessageID
+	})
+
+	return keys
+}
+
+// ReactionAggregator coalesces reaction updates in memory and writes them to DB in one transaction
+// when buffer reaches FlushSize or every FlushInterval.
+type ReactionAggregator struct {
+	embedlog.Logger
+	dbo db.DB
+	cr  db.CommonRepo
+	cfg AggregatorConfig
+
+	mu    sync.Mutex
+	batch *reactionBatch
+
+	flushMu sync.Mutex
+	flushCh chan struct{}
+	// writeBatch writes batch to DB in one transaction.
+	writeBatch func(ctx context.Context, batch *reactionBatch) error
+
+	depth         prometheus.Gauge
+	flushDuration prometheus.Histogram
+	flushErrors   prometheus.Counter
+}
+
+// NewReactionAggregator returns new reaction write buffer.
+func NewReactionAggregator(appName string, logger embedlog.Logger, dbo db.DB, cfg AggregatorConfig) *ReactionAggregator {
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = defaultFlushInterval
+	}
+	if cfg.FlushSize <= 0 {
+		cfg.FlushSize = defaultFlushSize
+	}
+
+	ra := &ReactionAggregator{
+		Logger:  logger,
+		dbo:     dbo,
+		cr:      db.NewCommonRepo(dbo),
+		cfg:     cfg,
+		batch:   newReactionBatch(),
+		flushCh: make(chan struct{}, 1),
+		depth: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: appName,
+			Subsystem: "reactions",
+			Name:      "buffer_depth",
+			Help:      "Number of reaction updates waiting for flush.",
+		}),
+		flushDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: appName,
+			Subsystem: "reactions",
+			Name:      "flush_duration_seconds",
+			Help:      "Reaction buffer flush latency.",
+			Buckets:   prometheus.DefBuckets,
+		}),
+		flushErrors: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: appName,
+			Subsystem: "reactions",
+			Name:      "flush_errors_total",
+			Help:      "Number of failed reaction buffer flushes.",
+		}),
+	}
+	ra.writeBatch = func(ctx context.Context, batch *reactionBatch) error {
+		return ra.dbo.RunInTransaction(ctx, func(tx *pg.Tx) error {
+			return ra.write(ctx, ra.cr.WithTransaction(tx), batch)
+		})
+	}
+
+	return ra
+}
+
+var _ prometheus.Collector = (*ReactionAggregator)(nil)
+
+// Describe describes all the embedded prometheus metrics.
+func (ra *ReactionAggregator) Describe(ch chan<- *prometheus.Desc) {
+	ra.depth.Describe(ch)
+	ra.flushDuration.Describe(ch)
+	ra.flushErrors.Describe(ch)
+}
+
+// Collect collects all the embedded prometheus metrics.
+func (ra *ReactionAggregator) Collect(ch chan<- prometheus.Metric) {
+	ra.depth.Collect(ch)
+	ra.flushDuration.Collect(ch)
+	ra.flushErrors.Collect(ch)
+}
+
+// AddReaction buffers user reaction change. user is nil for reactions of channels and anonymous admins.
+func (ra *ReactionAggregator) AddReaction(mk messageKey, deltas map[reactionKey]int, reactors int, date time.Time, events []db.ReactionEvent, user *db.User) {
+	ra.add(func(rb *reactionBatch) {
+		rb.addDeltas(mk, deltas, reactors, date)
+		rb.events = append(rb.events, events...)
+		if user != nil {
+			rb.users[user.ID] = *user
+		}
+	})
+}
+
+// SyncCounts buffers authoritative reaction totals of the message.
+func (ra *ReactionAggregator) SyncCounts(mk messageKey, total int, syncedAt time.Time, counts []db.MessageReactionCount) {
+	ra.add(func(rb *reactionBatch) {
+		rb.addSync(mk, reactionSync{total: total, syncedAt: syncedAt, counts: counts})
+	})
+}
+
+func (ra *ReactionAggregator) add(fn func(rb *reactionBatch)) {
+	ra.mu.Lock()
+	fn(ra.batch)
+	ra.batch.updates++
+	updates := ra.batch.updates
+	ra.mu.Unlock()
+
+	ra.depth.Set(float64(updates))
+	if updates >= ra.cfg.FlushSize {
+		select {
+		case ra.flushCh <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Run flushes buffer by timer or size threshold until ctx is done, then flushes the rest. ctx should be done after
+// bot handlers are stopped, so updates are not added after the last flush.
+func (ra *ReactionAggregator) Run(ctx context.Context) {
+	ticker := time.NewTicker(ra.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			if err := ra.Flush(context.Background()); err != nil {
+				ra.Errorf("final flush reactions err=%q", err)
+			}
+			return
+		case <-ticker.C:
+		case <-ra.flushCh:
+		}
+
+		if err := ra.Flush(context.Background()); err != nil {
+			ra.Errorf("flush reactions err=%q", err)
+		}
+	}
+}
+
+// Flush writes all buffered updates to DB in one transaction. On error updates are returned to buffer.
+func (ra *ReactionAggregator) Flush(ctx context.Context) error {
+	ra.flushMu.Lock()
+	defer ra.flushMu.Unlock()
+
+	ra.mu.Lock()
+	batch := ra.batch
+	ra.batch = newReactionBatch()
+	ra.mu.Unlock()
+
+	if batch.updates == 0 {
+		return nil
+	}
+
+	start := time.Now()
+	err := ra.writeBatch(ctx, batch)
+	ra.flushDuration.Observe(time.Since(start).Seconds())
+
+	if err != nil {
+		ra.flushErrors.Inc()
+		ra.mu.Lock()
+		batch.merge(ra.batch)
+		ra.batch = batch
+		ra.mu.Unlock()
+	}
+
+	ra.mu.Lock()
+	ra.depth.Set(float64(ra.batch.updates))
+	ra.mu.Unlock()
+
+	return err
+}
+
+// write applies batch: authoritative totals first, then newer deltas, message scores and events log.
+func (ra *ReactionAggregator) write(ctx context.Context, cr db.CommonRepo, batch *reactionBatch) error {
+	keys := batch.messageKeys()
+	for _, mk := range keys {
+		if rs, ok := batch.syncs[mk]; ok {
+			synced, delta, err := cr.SyncMessageReaction(ctx, mk.ChatID, mk.MessageID, rs.total, rs.syncedAt)
+			if err != nil {
+				return err
+			}
+			if synced {
+				if err = cr.SetMessageReactionCounts(ctx, mk.ChatID, mk.MessageID, rs.counts); err != nil {
+					return err
+				}
+			}
+			if delta != 0 {
+				if err = cr.IncrementReactionBucket(ctx, mk.ChatID, mk.MessageID, rs.syncedAt, delta); err != nil {
+					return err
+				}
+			}
+		}
+
+		md, ok := batch.deltas[mk]
+		if !ok {
+			continue
+		}
+
+		if md.reactions != 0 || md.reactors != 0 {
+			if err := cr.IncrementMessageReaction(ctx, mk.ChatID, mk.MessageID, md.reactions, md.reactors); err != nil {
+				return err
+			}
+		}
+
+		for key, delta := range md.counts {
+			if delta == 0 {
+				continue
+			}
+			if err := cr.IncrementMessageReactionCount(ctx, mk.ChatID, mk.MessageID, key.Type, key.Reaction, delta); err != nil {
+				return err
+			}
+		}
+
+		for bucketAt, delta := range md.buckets {
+			if delta == 0 {
+				continue
+			}
+			if err := cr.IncrementReactionBucket(ctx, mk.ChatID, mk.MessageID, bucketAt, delta); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := ra.updateScores(ctx, cr, keys); err != nil {
+		return err
+	}
+
+	if err := cr.AddReactionEvents(ctx, batch.events); err != nil {
+		return err
+	}
+
+	return cr.SaveUsers(ctx, batch.sortedUsers())
+}
+
+// updateScores recalculates scores of changed messages chat by chat, keys are sorted by chat.
+func (ra *ReactionAggregator) updateScores(ctx context.Context, cr db.CommonRepo, keys []messageKey) error {
+	for i := 0; i < len(keys); {
+		j := i
+		messageIDs := make([]int, 0, len(keys)-i)
+		for ; j < len(keys) && keys[j].ChatID == keys[i].ChatID; j++ {
+			messageIDs = append(messageIDs, keys[j].MessageID)
+		}
+
+		if err := cr.UpdateMessageReactionScores(ctx, keys[i].ChatID, messageIDs); err != nil {
+			return err
+		}
+		i = j
+	}
+
+	return nil
+}
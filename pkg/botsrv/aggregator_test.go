@@ -0,0 +1,66 @@
+package botsrv
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"botsrv/pkg/db"
+	"botsrv/pkg/embedlog"
+)
+
+func TestReactionAggregator_RunFlushesOnShutdown(t *testing.T) {
+	// interval and size never trigger flush, so buffered reactions are written by the final flush only
+	ra := NewReactionAggregator("test", embedlog.Logger{}, db.DB{}, AggregatorConfig{FlushInterval: time.Hour, FlushSize: 1 << 20})
+
+	var (
+		mu      sync.Mutex
+		written int
+	)
+	ra.writeBatch = func(ctx context.Context, batch *reactionBatch) error {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, md := range batch.deltas {
+			written += md.reactions
+		}
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ra.Run(ctx)
+	}()
+
+	// handlers add reactions concurrently until bot is stopped
+	const workers, reactions = 8, 100
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < reactions; i++ {
+				mk := messageKey{ChatID: -100, MessageID: w*reactions + i}
+				deltas := map[reactionKey]int{{Type: "emoji", Reaction: "👍"}: 1}
+				ra.AddReaction(mk, deltas, 1, time.Now(), nil, nil)
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run is not stopped")
+	}
+
+	if want := workers * reactions; written != want {
+		t.Errorf("written reactions = %d, want %d", written, want)
+	}
+	if ra.batch.updates != 0 {
+		t.Errorf("buffered updates = %d, want 0", ra.batch.updates)
+	}
+}
@@ -0,0 +1,195 @@
+package botsrv
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"botsrv/pkg/db"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+const authorsPageSize = 10
+
+// authorsRequest is the authors leaderboard parameters encoded in callback data like "authors:week:2".
+type authorsRequest struct {
+	Period string
+	Page   int
+}
+
+// parseAuthorsRequest parses callback data "authors:<period>:<page>".
+func parseAuthorsRequest(data string) authorsRequest {
+	period, page, _ := strings.Cut(strings.TrimPrefix(data, patternAuthors), ":")
+	req := authorsRequest{Period: period, Page: 1}
+	if n, err := strconv.Atoi(page); err == nil && n > 1 {
+		req.Page = n
+	}
+
+	return req
+}
+
+// CallbackData returns callback data of the request.
+func (r authorsRequest) CallbackData() string {
+	return patternAuthors + r.Period + ":" + strconv.Itoa(r.Page)
+}
+
+// authorsKeyboard returns pagination keyboard of authors leaderboard, nil for single page.
+func authorsKeyboard(l localizer, req authorsRequest, pages int) *models.InlineKeyboardMarkup {
+	var row []models.InlineKeyboardButton
+	if req.Page > 1 {
+		prev := req
+		prev.Page--
+		row = append(row, models.InlineKeyboardButton{Text: l.T("authors.prev"), CallbackData: prev.CallbackData()})
+	}
+	if req.Page < pages {
+		next := req
+		next.Page++
+		row = append(row, models.InlineKeyboardButton{Text: l.T("authors.next"), CallbackData: next.CallbackData()})
+	}
+	if len(row) == 0 {
+		return nil
+	}
+
+	return &models.InlineKeyboardMarkup{InlineKeyboard: [][]models.InlineKeyboardButton{row}}
+}
+
+// authorsText builds authors leaderboard page of the chat ranked by reactions received by their messages.
+// Returns count of pages.
+func (bm *BotManager) authorsText(ctx context.Context, l localizer, cs db.ChatSetting, req authorsRequest) (string, int, error) {
+	period, err := parsePeriod(req.Period, time.Now(), settingLocation(cs))
+	if err != nil {
+		return "", 0, fmt.Errorf("incorrect period=%q: %w", req.Period, err)
+	}
+
+	search := chatReactionSearch(cs)
+	if period.Windowed() {
+		search.WithReactedInPeriod(period.From, period.To)
+	}
+
+	total, err := bm.cr.CountAuthorStats(ctx, search)
+	if err != nil {
+		return "", 0, fmt.Errorf("count authors: %w", err)
+	}
+	pages := (total + authorsPageSize - 1) / authorsPageSize
+
+	header := l.T("authors.header", period.title(l))
+	if pages > 1 {
+		header += " (" + l.T("authors.page", req.Page, pages) + ")"
+	}
+	res := "<b>" + header + "</b>"
+	if total == 0 {
+		return res + "\n\n" + l.T("no_reactions"), pages, nil
+	}
+
+	stats, err := bm.cr.AuthorStatsByFilters(ctx, search, db.Pager{Page: req.Page, PageSize: authorsPageSize}, period.From, period.To)
+	if err != nil {
+		return "", 0, fmt.Errorf("fetch authors: %w", err)
+	}
+
+	authorIDs := make([]int64, 0, len(stats))
+	for _, stat := range stats {
+		authorIDs = append(authorIDs, stat.AuthorID)
+	}
+
+	reactors, err := bm.cr.AuthorReactorCounts(ctx, cs.ChatID, authorIDs, period.From, period.To)
+	if err != nil {
+		return "", 0, fmt.Errorf("fetch author reactors: %w", err)
+	}
+
+	for i, stat := range stats {
+		name := "#" + strconv.FormatInt(stat.AuthorID, 10)
+		if stat.AuthorName != nil {
+			name = *stat.AuthorName
+		}
+
+		res += "\n\n" + placeMark((req.Page-1)*authorsPageSize+i) + " <b>" + escapeHTML(name) + "</b>\n" +
+			l.N("reactions", stat.ReactionsCount) + ", " + l.N("reactors", reactors[stat.AuthorID]) + ", " + l.N("messages", stat.MessagesCount)
+	}
+
+	return res, pages, nil
+}
+
+// TopAuthorsHandler sends authors leaderboard for period from command arguments like "/topauthors week",
+// chat default period is used without arguments.
+func (bm *BotManager) TopAuthorsHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	m := update.Message
+	if m == nil || !isTrackedChat(m.Chat) {
+		return
+	}
+
+	cs, err := bm.chatSetting(ctx, m.Chat.ID)
+	if err != nil {
+		bm.Errorf("%v", err)
+		return
+	}
+	l := chatLocalizer(cs, m.From)
+
+	req := authorsRequest{Period: cs.Period, Page: 1}
+	if args := strings.Fields(m.Text)[1:]; len(args) > 0 {
+		req.Period = strings.ToLower(strings.Join(args, ""))
+	}
+
+	params := &bot.SendMessageParams{ChatID: m.Chat.ID, MessageThreadID: m.MessageThreadID}
+	if _, err = parsePeriod(req.Period, time.Now(), settingLocation(cs)); err != nil {
+		params.Text = l.E(err) + "\n\n" + l.T("period.help", authorsCommand)
+	} else {
+		text, pages, err := bm.authorsText(ctx, l, cs, req)
+		if err != nil {
+			bm.Errorf("Failed to build authors: %v", err)
+			return
+		}
+
+		params.Text, params.ParseMode = text, models.ParseModeHTML
+		if kb := authorsKeyboard(l, req, pages); kb != nil {
+			params.ReplyMarkup = kb
+		}
+	}
+
+	if _, err = b.SendMessage(ctx, params); err != nil {
+		bm.Errorf("%v", err)
+		return
+	}
+}
+
+// AuthorsCallbackHandler switches authors leaderboard page.
+func (bm *BotManager) AuthorsCallbackHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	cq := update.CallbackQuery
+	if cq == nil || cq.Message.Message == nil {
+		return
+	}
+	defer bm.answerCallback(ctx, b, &bot.AnswerCallbackQueryParams{CallbackQueryID: cq.ID})
+
+	msg := cq.Message.Message
+	req := parseAuthorsRequest(cq.Data)
+	cs, err := bm.chatSetting(ctx, msg.Chat.ID)
+	if err != nil {
+		bm.Errorf("%v", err)
+		return
+	}
+	l := chatLocalizer(cs, &cq.From)
+
+	text, pages, err := bm.authorsText(ctx, l, cs, req)
+	if err != nil {
+		bm.Errorf("Failed to build authors: %v", err)
+		return
+	}
+
+	params := &bot.EditMessageTextParams{
+		ChatID:    msg.Chat.ID,
+		MessageID: msg.ID,
+		Text:      text,
+		ParseMode: models.ParseModeHTML,
+	}
+	if kb := authorsKeyboard(l, req, pages); kb != nil {
+		params.ReplyMarkup = kb
+	}
+
+	if _, err = b.EditMessageText(ctx, params); err != nil {
+		bm.Errorf("%v", err)
+		return
+	}
+}
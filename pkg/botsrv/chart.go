@@ -0,0 +1,263 @@
+package botsrv
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"strings"
+	"time"
+
+	"botsrv/pkg/db"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+const (
+	chartWidth     = 800
+	chartHeight    = 400
+	chartMargin    = 24
+	chartGridLines = 4
+	// chartMaxBars is max count of points drawn as bars, longer series are drawn as line.
+	chartMaxBars = 48
+	// chartHourlyPeriod is the longest period charted by hours, longer periods are charted by days.
+	chartHourlyPeriod = 72 * time.Hour
+
+	chartUnitHour = "hour"
+	chartUnitDay  = "day"
+)
+
+var (
+	chartBackground = color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+	chartGrid       = color.RGBA{R: 0xe5, G: 0xe7, B: 0xeb, A: 0xff}
+	chartAxis       = color.RGBA{R: 0x9c, G: 0xa3, B: 0xaf, A: 0xff}
+	chartLine       = color.RGBA{R: 0x3b, G: 0x82, B: 0xf6, A: 0xff}
+	chartArea       = color.RGBA{R: 0xbf, G: 0xdb, B: 0xfe, A: 0xff}
+)
+
+// chartSeries is reactions count by hour or day, units without reactions are zero.
+type chartSeries struct {
+	Unit   string
+	Start  time.Time
+	Values []int
+}
+
+// newChartSeries fills gaps between points from start till to with zeros.
+func newChartSeries(points []db.ReactionPoint, unit string, start, to time.Time) chartSeries {
+	next := func(t time.Time) time.Time { return t.Add(time.Hour) }
+	if unit == chartUnitDay {
+		next = func(t time.Time) time.Time { return t.AddDate(0, 0, 1) }
+	}
+
+	cs := chartSeries{Unit: unit, Start: start}
+	for t, i := start, 0; t.Before(to); t = next(t) {
+		v := 0
+		for ; i < len(points) && !points[i].At.After(t); i++ {
+			if points[i].At.Equal(t) {
+				v = points[i].ReactionsCount
+			}
+		}
+		cs.Values = append(cs.Values, v)
+	}
+
+	return cs
+}
+
+// Peak returns the time and value of the max point.
+func (cs chartSeries) Peak() (time.Time, int) {
+	peak := 0
+	for i, v := range cs.Values {
+		if v > cs.Values[peak] {
+			peak = i
+		}
+	}
+
+	if cs.Unit == chartUnitDay {
+		return cs.Start.AddDate(0, 0, peak), cs.Values[peak]
+	}
+
+	return cs.Start.Add(time.Duration(peak) * time.Hour), cs.Values[peak]
+}
+
+// Total returns sum of values.
+func (cs chartSeries) Total() int {
+	total := 0
+	for _, v := range cs.Values {
+		total += v
+	}
+
+	return total
+}
+
+// fillRect fills rectangle of img with c.
+func fillRect(img draw.Image, r image.Rectangle, c color.Color) {
+	draw.Draw(img, r, &image.Uniform{C: c}, image.Point{}, draw.Src)
+}
+
+// renderChart renders values as PNG bar chart, or area line chart for long series. Values are scaled to the max one,
+// negative values are drawn as zero. Rendering is deterministic, the same values give the same image.
+func renderChart(values []int) ([]byte, error) {
+	img := image.NewRGBA(image.Rect(0, 0, chartWidth, chartHeight))
+	fillRect(img, img.Bounds(), chartBackground)
+
+	plot := image.Rect(chartMargin, chartMargin, chartWidth-chartMargin, chartHeight-chartMargin)
+	for i := chartGridLines; i >= 0; i-- {
+		y := plot.Max.Y - plot.Dy()*i/chartGridLines
+		c := chartGrid
+		if i == 0 {
+			c = chartAxis
+		}
+		fillRect(img, image.Rect(plot.Min.X, y, plot.Max.X, y+1), c)
+	}
+
+	maxValue := 0
+	for _, v := range values {
+		if v > maxValue {
+			maxValue = v
+		}
+	}
+
+	n := len(values)
+	y := func(i int) int {
+		v := values[i]
+		if v < 0 {
+			v = 0
+		}
+		return plot.Max.Y - plot.Dy()*v/maxValue
+	}
+
+	switch {
+	case maxValue == 0:
+	case n <= chartMaxBars:
+		for i := range values {
+			x0, x1 := plot.Min.X+plot.Dx()*i/n, plot.Min.X+plot.Dx()*(i+1)/n
+			gap := (x1 - x0) / 5
+			fillRect(img, image.Rect(x0+gap, y(i), x1-gap, plot.Max.Y), chartLine)
+		}
+	default:
+		x := func(i int) int { return plot.Min.X + plot.Dx()*i/(n-1) }
+		prevY := y(0)
+		for i := 0; i < n-1; i++ {
+			x0, x1, y0, y1 := x(i), x(i+1), y(i), y(i+1)
+			for px := x0; px <= x1; px++ {
+				py := y0
+				if x1 > x0 {
+					py = y0 + (y1-y0)*(px-x0)/(x1-x0)
+				}
+				top, bottom := prevY, py
+				if top > bottom {
+					top, bottom = bottom, top
+				}
+				fillRect(img, image.Rect(px, py, px+1, plot.Max.Y), chartArea)
+				fillRect(img, image.Rect(px, top-1, px+2, bottom+2), chartLine)
+				prevY = py
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// chartSeriesOf returns reactions of chat messages by hours for short periods and by days for others.
+// All time series starts with the first reaction.
+func (bm *BotManager) chartSeriesOf(ctx context.Context, chatID int64, period digestPeriod, loc *time.Location) (chartSeries, error) {
+	unit := chartUnitDay
+	if period.Windowed() && period.To.Sub(period.From) <= chartHourlyPeriod {
+		unit = chartUnitHour
+	}
+
+	points, err := bm.cr.ChatReactionSeries(ctx, chatID, period.From, period.To, unit, loc)
+	if err != nil || len(points) == 0 {
+		return chartSeries{}, err
+	}
+
+	from := period.From.In(loc)
+	switch {
+	case !period.Windowed():
+		from = points[0].At
+	case unit == chartUnitHour:
+		// truncated in loc, absolute truncation breaks zones with non-whole hour offsets like Asia/Kolkata
+		from = time.Date(from.Year(), from.Month(), from.Day(), from.Hour(), 0, 0, 0, loc)
+	default:
+		from = time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, loc)
+	}
+
+	return newChartSeries(points, unit, from, period.To), nil
+}
+
+// ChartHandler sends PNG chart of reactions by hours or days for period from command arguments like "/chart week",
+// chat default period is used without arguments.
+func (bm *BotManager) ChartHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	m := update.Message
+	if m == nil || !isTrackedChat(m.Chat) {
+		return
+	}
+
+	cs, err := bm.chatSetting(ctx, m.Chat.ID)
+	if err != nil {
+		bm.Errorf("%v", err)
+		return
+	}
+	l := chatLocalizer(cs, m.From)
+	loc := settingLocation(cs)
+
+	spec := cs.Period
+	if args := strings.Fields(m.Text)[1:]; len(args) > 0 {
+		spec = strings.ToLower(strings.Join(args, ""))
+	}
+
+	params := &bot.SendMessageParams{ChatID: m.Chat.ID, MessageThreadID: m.MessageThreadID}
+	period, err := parsePeriod(spec, time.Now(), loc)
+	if err != nil {
+		params.Text = l.E(err) + "\n\n" + l.T("period.help", chartCommand)
+		if _, err = b.SendMessage(ctx, params); err != nil {
+			bm.Errorf("%v", err)
+		}
+		return
+	}
+
+	series, err := bm.chartSeriesOf(ctx, m.Chat.ID, period, loc)
+	if err != nil {
+		bm.Errorf("Failed to fetch chart: %v", err)
+		return
+	} else if series.Total() <= 0 {
+		params.Text = l.T("no_reactions")
+		if _, err = b.SendMessage(ctx, params); err != nil {
+			bm.Errorf("%v", err)
+		}
+		return
+	}
+
+	chart, err := renderChart(series.Values)
+	if err != nil {
+		bm.Errorf("Failed to render chart: %v", err)
+		return
+	}
+
+	peakAt, peak := series.Peak()
+	layout := l.T("period.date")
+	if series.Unit == chartUnitHour {
+		layout += " 15:04"
+	}
+
+	_, err = b.SendPhoto(ctx, &bot.SendPhotoParams{
+		ChatID:          m.Chat.ID,
+		MessageThreadID: m.MessageThreadID,
+		Photo:           &models.InputFileUpload{Filename: "chart.png", Data: bytes.NewReader(chart)},
+		Caption: "<b>" + escapeHTML(l.T("chart.header", period.title(l))) + "</b>\n" +
+			escapeHTML(l.T("chart.summary", series.Total(), peak, peakAt.Format(layout))),
+		ParseMode: models.ParseModeHTML,
+	})
+	if err != nil {
+		bm.Errorf("%v", err)
+		return
+	}
+}
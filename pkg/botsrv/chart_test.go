@@ -0,0 +1,111 @@
+package botsrv
+
+import (
+	"bytes"
+	"fmt"
+	"image/png"
+	"testing"
+	"time"
+
+	"botsrv/pkg/db"
+)
+
+func TestRenderChart(t *testing.T) {
+	line := make([]int, 24*7)
+	for i := range line {
+		line[i] = (i % 24) * (i % 5)
+	}
+
+	tests := []struct {
+		name   string
+		values []int
+	}{
+		{name: "empty", values: []int{0, 0, 0}},
+		{name: "bar", values: []int{3, 0, 7, 12, -1, 5, 1}},
+		{name: "line", values: line},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := renderChart(tt.values)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			img, err := png.Decode(bytes.NewReader(got))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if b := img.Bounds(); b.Dx() != chartWidth || b.Dy() != chartHeight {
+				t.Errorf("chart size = %dx%d, want %dx%d", b.Dx(), b.Dy(), chartWidth, chartHeight)
+			}
+
+			assertGolden(t, "chart_"+tt.name+".png", got)
+		})
+	}
+}
+
+func TestNewChartSeries(t *testing.T) {
+	loc, err := time.LoadLocation("Asia/Kolkata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2025, 9, 1, 10, 0, 0, 0, loc)
+	at := func(h int) time.Time { return start.Add(time.Duration(h) * time.Hour) }
+
+	tests := []struct {
+		name   string
+		unit   string
+		points []db.ReactionPoint
+		to     time.Time
+		want   []int
+	}{
+		{
+			name:   "hour gaps",
+			unit:   chartUnitHour,
+			points: []db.ReactionPoint{{At: at(1), ReactionsCount: 3}, {At: at(4), ReactionsCount: 5}},
+			to:     at(6),
+			want:   []int{0, 3, 0, 0, 5, 0},
+		},
+		{
+			name:   "points before start and after end",
+			unit:   chartUnitHour,
+			points: []db.ReactionPoint{{At: at(-1), ReactionsCount: 2}, {At: at(0), ReactionsCount: 1}, {At: at(3), ReactionsCount: 9}},
+			to:     at(3),
+			want:   []int{1, 0, 0},
+		},
+		{
+			name: "day gaps",
+			unit: chartUnitDay,
+			points: []db.ReactionPoint{
+				{At: start.AddDate(0, 0, 1), ReactionsCount: 4},
+				{At: start.AddDate(0, 0, 3), ReactionsCount: 1},
+			},
+			to:   start.AddDate(0, 0, 4),
+			want: []int{0, 4, 0, 1},
+		},
+		{
+			name: "no points",
+			unit: chartUnitDay,
+			to:   start.AddDate(0, 0, 2),
+			want: []int{0, 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cs := newChartSeries(tt.points, tt.unit, start, tt.to)
+			if fmt.Sprint(cs.Values) != fmt.Sprint(tt.want) {
+				t.Errorf("newChartSeries() values = %v, want %v", cs.Values, tt.want)
+			}
+		})
+	}
+
+	cs := newChartSeries([]db.ReactionPoint{{At: at(2), ReactionsCount: 7}, {At: at(3), ReactionsCount: 2}}, chartUnitHour, start, at(5))
+	if peakAt, peak := cs.Peak(); !peakAt.Equal(at(2)) || peak != 7 {
+		t.Errorf("Peak() = %v, %d, want %v, 7", peakAt, peak, at(2))
+	}
+	if total := cs.Total(); total != 9 {
+		t.Errorf("Total() = %d, want 9", total)
+	}
+}
@@ -0,0 +1,239 @@
+package botsrv
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"botsrv/pkg/db"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+const (
+	defaultChatCleanupDelay = 30 * 24 * time.Hour
+	chatCleanupInterval     = time.Hour
+)
+
+// chatLockName returns name of DB lock for chat data changes.
+func chatLockName(chatID int64) string {
+	return "chat:" + strconv.FormatInt(chatID, 10)
+}
+
+// isChatMember returns true if chat member status means presence in the chat.
+func isChatMember(cm models.ChatMember) bool {
+	switch cm.Type {
+	case models.ChatMemberTypeOwner, models.ChatMemberTypeAdministrator, models.ChatMemberTypeMember:
+		return true
+	case models.ChatMemberTypeRestricted:
+		return cm.Restricted != nil && cm.Restricted.IsMember
+	}
+
+	return false
+}
+
+// newChatAdminRights returns bot admin rights or nil if bot is not an administrator.
+func newChatAdminRights(cm models.ChatMember) *db.ChatAdminRights {
+	a := cm.Administrator
+	if a == nil {
+		return nil
+	}
+
+	return &db.ChatAdminRights{
+		IsAnonymous:         a.IsAnonymous,
+		CanManageChat:       a.CanManageChat,
+		CanDeleteMessages:   a.CanDeleteMessages,
+		CanManageVideoChats: a.CanManageVideoChats,
+		CanRestrictMembers:  a.CanRestrictMembers,
+		CanPromoteMembers:   a.CanPromoteMembers,
+		CanChangeInfo:       a.CanChangeInfo,
+		CanInviteUsers:      a.CanInviteUsers,
+		CanPostMessages:     a.CanPostMessages,
+		CanEditMessages:     a.CanEditMessages,
+		CanPinMessages:      a.CanPinMessages,
+		CanManageTopics:     a.CanManageTopics,
+	}
+}
+
+// newChat converts telegram chat into chat registry entry without membership info.
+func newChat(c models.Chat) *db.Chat {
+	chat := &db.Chat{
+		ID:        c.ID,
+		Type:      string(c.Type),
+		IsForum:   c.IsForum,
+		UpdatedAt: time.Now(),
+	}
+	if c.Title != "" {
+		chat.Title = &c.Title
+	}
+	if c.Username != "" {
+		chat.Username = &c.Username
+	}
+
+	return chat
+}
+
+// chatModel converts chat registry entry into telegram chat used for digests.
+func chatModel(c *db.Chat) models.Chat {
+	chat := models.Chat{
+		ID:      c.ID,
+		Type:    models.ChatType(c.Type),
+		IsForum: c.IsForum,
+	}
+	if c.Title != nil {
+		chat.Title = *c.Title
+	}
+	if c.Username != nil {
+		chat.Username = *c.Username
+	}
+
+	return chat
+}
+
+// ensureChat adds chat where bot got a message to registry if it is missing.
+func (bm *BotManager) ensureChat(ctx context.Context, c models.Chat) error {
+	chat := newChat(c)
+	chat.MemberStatus = string(models.ChatMemberTypeMember)
+
+	return bm.cr.EnsureChat(ctx, chat)
+}
+
+// registerChat adds chat of incoming update to registry once per process, so chats bot joined before registry
+// existed are found by their members.
+func (bm *BotManager) registerChat(ctx context.Context, c models.Chat) error {
+	if !isTrackedChat(c) {
+		return nil
+	} else if _, ok := bm.registered.Load(c.ID); ok {
+		return nil
+	}
+
+	if err := bm.ensureChat(ctx, c); err != nil {
+		return fmt.Errorf("register chat: %w", err)
+	}
+	bm.registered.Store(c.ID, struct{}{})
+
+	return nil
+}
+
+// isChatAdmin returns true if message is sent by chat administrator, including anonymous ones.
+func (bm *BotManager) isChatAdmin(ctx context.Context, b *bot.Bot, m *models.Message) (bool, error) {
+	if m.SenderChat != nil {
+		return m.SenderChat.ID == m.Chat.ID, nil
+	}
+	if m.From == nil {
+		return false, nil
+	}
+
+	return bm.isChatAdminUser(ctx, b, m.Chat.ID, m.From.ID)
+}
+
+// isChatAdminUser returns true if user is chat owner or administrator.
+func (bm *BotManager) isChatAdminUser(ctx context.Context, b *bot.Bot, chatID, userID int64) (bool, error) {
+	cm, err := b.GetChatMember(ctx, &bot.GetChatMemberParams{ChatID: chatID, UserID: userID})
+	if err != nil {
+		return false, err
+	}
+
+	return cm.Type == models.ChatMemberTypeOwner || cm.Type == models.ChatMemberTypeAdministrator, nil
+}
+
+// saveMyChatMember updates chat registry on bot membership change. When bot leaves or is kicked, chat data is
+// scheduled for cleanup after grace period; adding bot back cancels it.
+func (bm *BotManager) saveMyChatMember(ctx context.Context, cmu *models.ChatMemberUpdated) error {
+	if !isTrackedChat(cmu.Chat) {
+		return nil
+	}
+
+	date := time.Unix(int64(cmu.Date), 0)
+
+	chat := newChat(cmu.Chat)
+	chat.MemberStatus = string(cmu.NewChatMember.Type)
+	chat.AdminRights = newChatAdminRights(cmu.NewChatMember)
+
+	switch {
+	case isChatMember(cmu.NewChatMember):
+		if !isChatMember(cmu.OldChatMember) {
+			chat.JoinedAt = &date
+		}
+	default:
+		cleanupAt := date.Add(bm.cfg.ChatCleanupDelay)
+		chat.LeftAt = &date
+		chat.CleanupAt = &cleanupAt
+	}
+
+	return bm.cr.SaveChatMember(ctx, chat)
+}
+
+// saveChatInfo refreshes title, username and forum flag of known chat.
+func (bm *BotManager) saveChatInfo(ctx context.Context, c models.Chat) error {
+	if !isTrackedChat(c) {
+		return nil
+	}
+
+	_, err := bm.cr.UpdateChatInfo(ctx, newChat(c))
+	return err
+}
+
+// migrateChat moves stored data of basic group to the supergroup it was upgraded to. Telegram sends both
+// migrate_to_chat_id to the group and migrate_from_chat_id to the supergroup, the second call finds nothing to move.
+// Buffered reactions are flushed first, so none of them are left behind under the group ID.
+func (bm *BotManager) migrateChat(ctx context.Context, fromChatID, toChatID int64) error {
+	if err := bm.ra.Flush(ctx); err != nil {
+		return err
+	}
+
+	err := bm.dbo.RunInLock(ctx, chatLockName(fromChatID), func(tx *pg.Tx) error {
+		return bm.cr.WithTransaction(tx).MigrateChat(ctx, fromChatID, toChatID)
+	})
+	if err != nil {
+		return err
+	}
+
+	bm.Printf("migrated chat %d to %d", fromChatID, toChatID)
+	return nil
+}
+
+// RunChatCleanup deletes data of chats the bot was removed from once their grace period is over.
+func (bm *BotManager) RunChatCleanup(ctx context.Context) {
+	ticker := time.NewTicker(chatCleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := bm.cleanupChats(ctx); err != nil {
+			bm.Errorf("cleanup chats err=%q", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (bm *BotManager) cleanupChats(ctx context.Context) error {
+	now := time.Now()
+	chats, err := bm.cr.ChatsByFilters(ctx, &db.ChatSearch{CleanupTo: &now}, db.PagerNoLimit)
+	if err != nil {
+		return err
+	}
+
+	for _, chat := range chats {
+		var deleted bool
+		err = bm.dbo.RunInLock(ctx, chatLockName(chat.ID), func(tx *pg.Tx) (err error) {
+			deleted, err = bm.cr.WithTransaction(tx).DeleteChatData(ctx, chat.ID, now)
+			return
+		})
+		if err != nil {
+			return err
+		}
+		if deleted {
+			bm.Printf("deleted data of chat %d", chat.ID)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,132 @@
+package botsrv
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronSearchLimit is the max time span searched for the next cron match.
+const cronSearchLimit = 5 * 366 * 24 * time.Hour
+
+// cronField is a set of allowed values of one cron field.
+type cronField struct {
+	values map[int]bool
+	// any is true for "*" field.
+	any bool
+}
+
+func (f cronField) match(v int) bool {
+	return f.any || f.values[v]
+}
+
+// cronSchedule is parsed standard 5-field cron expression: minute hour day-of-month month day-of-week.
+type cronSchedule struct {
+	minute, hour, dom, month, dow cronField
+}
+
+// parseCron parses cron expression like "0 21 * * 1-5". Lists, ranges and steps are supported,
+// day of week is 0-7 where both 0 and 7 are Sunday.
+func parseCron(expr string) (cronSchedule, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return cronSchedule{}, fmt.Errorf("cron expression must have 5 fields, got %d", len(fields))
+	}
+
+	bounds := [5][2]int{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 7}}
+	parsed := make([]cronField, 5)
+	for i, field := range fields {
+		f, err := parseCronField(field, bounds[i][0], bounds[i][1])
+		if err != nil {
+			return cronSchedule{}, fmt.Errorf("cron field %q: %w", field, err)
+		}
+		parsed[i] = f
+	}
+
+	if parsed[4].values[7] {
+		parsed[4].values[0] = true
+	}
+
+	return cronSchedule{minute: parsed[0], hour: parsed[1], dom: parsed[2], month: parsed[3], dow: parsed[4]}, nil
+}
+
+func parseCronField(field string, min, max int) (cronField, error) {
+	if field == "*" {
+		return cronField{any: true}, nil
+	}
+
+	f := cronField{values: make(map[int]bool)}
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			s, err := strconv.Atoi(part[i+1:])
+			if err != nil || s <= 0 {
+				return f, errors.New("invalid step")
+			}
+			step, part = s, part[:i]
+		}
+
+		lo, hi := min, max
+		if part != "*" {
+			bounds := strings.SplitN(part, "-", 2)
+			var err error
+			if lo, err = strconv.Atoi(bounds[0]); err != nil {
+				return f, errors.New("invalid value")
+			}
+			hi = lo
+			if len(bounds) == 2 {
+				if hi, err = strconv.Atoi(bounds[1]); err != nil {
+					return f, errors.New("invalid range")
+				}
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return f, fmt.Errorf("value out of range %d-%d", min, max)
+		}
+
+		for v := lo; v <= hi; v += step {
+			f.values[v] = true
+		}
+	}
+
+	return f, nil
+}
+
+// matchDay returns true if day matches day-of-month and day-of-week fields. As in cron, if both fields are
+// restricted, day matches any of them.
+func (cs cronSchedule) matchDay(t time.Time) bool {
+	switch {
+	case cs.dom.any:
+		return cs.dow.match(int(t.Weekday()))
+	case cs.dow.any:
+		return cs.dom.match(t.Day())
+	}
+
+	return cs.dom.match(t.Day()) || cs.dow.match(int(t.Weekday()))
+}
+
+// Next returns the first matching time after t in location of t. Zero time is returned if nothing matches.
+func (cs cronSchedule) Next(t time.Time) time.Time {
+	loc := t.Location()
+	limit := t.Add(cronSearchLimit)
+	t = t.Truncate(time.Minute).Add(time.Minute)
+
+	for t.Before(limit) {
+		switch {
+		case !cs.month.match(int(t.Month())):
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		case !cs.matchDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		case !cs.hour.match(t.Hour()):
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+		case !cs.minute.match(t.Minute()):
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+
+	return time.Time{}
+}
@@ -0,0 +1,103 @@
+package botsrv
+
+import (
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+)
+
+// fieldValues returns sorted allowed values of cron field, "*" for any value.
+func fieldValues(f cronField) string {
+	if f.any {
+		return "*"
+	}
+
+	var values []int
+	for v := range f.values {
+		values = append(values, v)
+	}
+	sort.Ints(values)
+
+	return fmt.Sprint(values)
+}
+
+func TestParseCron(t *testing.T) {
+	tests := []struct {
+		name    string
+		expr    string
+		want    [5]string
+		wantErr bool
+	}{
+		{name: "daily", expr: "0 21 * * *", want: [5]string{"[0]", "[21]", "*", "*", "*"}},
+		{name: "list and range", expr: "0,30 9-11 1,15 * 1-5", want: [5]string{"[0 30]", "[9 10 11]", "[1 15]", "*", "[1 2 3 4 5]"}},
+		{name: "steps", expr: "*/15 8-20/4 * */3 *", want: [5]string{"[0 15 30 45]", "[8 12 16 20]", "*", "[1 4 7 10]", "*"}},
+		{name: "sunday as 7", expr: "0 12 * * 7", want: [5]string{"[0]", "[12]", "*", "*", "[0 7]"}},
+		{name: "extra spaces", expr: " 5  4 * * 0 ", want: [5]string{"[5]", "[4]", "*", "*", "[0]"}},
+		{name: "too few fields", expr: "0 21 * *", wantErr: true},
+		{name: "too many fields", expr: "0 21 * * * *", wantErr: true},
+		{name: "minute out of range", expr: "60 * * * *", wantErr: true},
+		{name: "day of month out of range", expr: "0 0 0 * *", wantErr: true},
+		{name: "day of week out of range", expr: "0 0 * * 8", wantErr: true},
+		{name: "reversed range", expr: "0 5-1 * * *", wantErr: true},
+		{name: "zero step", expr: "*/0 * * * *", wantErr: true},
+		{name: "invalid value", expr: "a * * * *", wantErr: true},
+		{name: "invalid range", expr: "1-b * * * *", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseCron(tt.expr)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseCron(%q) err = nil, want error", tt.expr)
+				}
+				return
+			} else if err != nil {
+				t.Fatal(err)
+			}
+
+			fields := [5]string{fieldValues(got.minute), fieldValues(got.hour), fieldValues(got.dom), fieldValues(got.month), fieldValues(got.dow)}
+			if fields != tt.want {
+				t.Errorf("parseCron(%q) = %v, want %v", tt.expr, fields, tt.want)
+			}
+		})
+	}
+}
+
+func TestCronSchedule_Next(t *testing.T) {
+	loc, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	date := func(month time.Month, day, hour, min int) time.Time {
+		return time.Date(2025, month, day, hour, min, 0, 0, loc)
+	}
+
+	tests := []struct {
+		name string
+		expr string
+		from time.Time
+		want time.Time
+	}{
+		{name: "same day", expr: "0 21 * * *", from: date(3, 3, 10, 0), want: date(3, 3, 21, 0)},
+		{name: "exact match is skipped", expr: "0 21 * * *", from: date(3, 3, 21, 0), want: date(3, 4, 21, 0)},
+		{name: "next weekday", expr: "0 9 * * 1", from: date(3, 4, 10, 0), want: date(3, 10, 9, 0)},
+		{name: "day of month or week", expr: "0 9 15 * 1", from: date(3, 11, 10, 0), want: date(3, 15, 9, 0)},
+		{name: "next month", expr: "0 0 1 * *", from: date(1, 31, 12, 0), want: date(2, 1, 0, 0)},
+		{name: "leap day", expr: "0 0 29 2 *", from: date(3, 1, 0, 0), want: time.Date(2028, 2, 29, 0, 0, 0, 0, loc)},
+		{name: "never", expr: "0 0 30 2 *", from: date(3, 1, 0, 0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cs, err := parseCron(tt.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := cs.Next(tt.from); !got.Equal(tt.want) {
+				t.Errorf("Next(%v) = %v, want %v", tt.from, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,422 @@
+package botsrv
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"botsrv/pkg/db"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+const (
+	digestSortReactions = "reactions"
+	digestSortReactors  = "reactors"
+	digestSortScore     = "score"
+	digestSortHot       = "hot"
+
+	// hotHalfLife is the age at which reaction weighs half in hot ranking.
+	hotHalfLife = 6 * time.Hour
+
+	// digestScopeAll marks digest over all forum topics.
+	digestScopeAll = "all"
+
+	digestPageSize    = 10
+	digestSortsPerRow = 2
+)
+
+// ReactionsPeriod is digest period, Title and Button are catalog keys.
+type ReactionsPeriod struct {
+	Title  string
+	Button string
+	Period time.Duration
+}
+
+var reactionPeriods = map[string]ReactionsPeriod{
+	patternDigestHour: {
+		Title:  "period.hour",
+		Button: "period.hour.button",
+		Period: 1 * time.Hour,
+	},
+	patternDigestDay: {
+		Title:  "period.day",
+		Button: "period.day.button",
+		Period: 24 * time.Hour,
+	},
+	patternDigestWeek: {
+		Title:  "period.week",
+		Button: "period.week.button",
+		Period: 24 * 7 * time.Hour,
+	},
+	patternDigestMonth: {
+		Title:  "period.month",
+		Button: "period.month.button",
+		Period: 24 * 30 * time.Hour,
+	},
+	patternDigestAll: {
+		Title:  "period.all",
+		Button: "period.all.button",
+	},
+}
+
+// DigestSort describes digest ranking metric: column or time-decayed reactions if HalfLife is set.
+// Title and Button are catalog keys.
+type DigestSort struct {
+	Title    string
+	Button   string
+	Column   string
+	HalfLife time.Duration
+}
+
+// sortOp returns sort option of the ranking metric.
+func (s DigestSort) sortOp(now time.Time) db.OpFunc {
+	if s.HalfLife > 0 {
+		return db.WithHotSort(s.HalfLife, now)
+	}
+
+	return db.WithSort(db.NewSortField(s.Column, true))
+}
+
+var digestSorts = map[string]DigestSort{
+	digestSortReactions: {
+		Title:  "sort.reactions",
+		Button: "sort.reactions.button",
+		Column: db.TablePrefix + "." + db.Columns.MessageReaction.ReactionsCount,
+	},
+	digestSortReactors: {
+		Title:  "sort.reactors",
+		Button: "sort.reactors.button",
+		Column: db.TablePrefix + "." + db.Columns.MessageReaction.ReactorsCount,
+	},
+	digestSortScore: {
+		Title:  "sort.score",
+		Button: "sort.score.button",
+		Column: db.TablePrefix + "." + db.Columns.MessageReaction.Score,
+	},
+	digestSortHot: {
+		Title:    "sort.hot",
+		Button:   "sort.hot.button",
+		HalfLife: hotHalfLife,
+	},
+}
+
+// digestSortOrder is the order of sort buttons under digest.
+var digestSortOrder = []string{digestSortReactions, digestSortReactors, digestSortScore, digestSortHot}
+
+// digestRequest is the digest parameters encoded in callback data like "digest:day:reactors:all".
+type digestRequest struct {
+	Period    string
+	Sort      string
+	AllTopics bool
+}
+
+// parseDigestRequest parses callback data "digest:<period>[:<sort>[:all]]".
+func parseDigestRequest(data string) digestRequest {
+	parts := strings.Split(strings.TrimPrefix(data, paternDigest), ":")
+	req := digestRequest{Period: parts[0], Sort: digestSortReactions}
+	if len(parts) > 1 && parts[1] != "" {
+		req.Sort = parts[1]
+	}
+	if len(parts) > 2 {
+		req.AllTopics = parts[2] == digestScopeAll
+	}
+
+	return req
+}
+
+// CallbackData returns callback data of the request.
+func (r digestRequest) CallbackData() string {
+	data := paternDigest + r.Period + ":" + r.Sort
+	if r.AllTopics {
+		data += ":" + digestScopeAll
+	}
+
+	return data
+}
+
+// digestPeriodKeyboard returns keyboard for choosing digest period. Chat default period is marked,
+// digests are sorted by chat default sort.
+func digestPeriodKeyboard(l localizer, cs db.ChatSetting) *models.InlineKeyboardMarkup {
+	kb := &models.InlineKeyboardMarkup{}
+	for i, period := range periodOrder {
+		text := l.T(reactionPeriods[paternDigest+period].Button)
+		if period == cs.Period {
+			text = "• " + text
+		}
+		button := models.InlineKeyboardButton{Text: text, CallbackData: digestRequest{Period: period, Sort: cs.Sort}.CallbackData()}
+		if i%2 == 0 {
+			kb.InlineKeyboard = append(kb.InlineKeyboard, nil)
+		}
+		last := len(kb.InlineKeyboard) - 1
+		kb.InlineKeyboard[last] = append(kb.InlineKeyboard[last], button)
+	}
+
+	last := len(kb.InlineKeyboard) - 1
+	kb.InlineKeyboard[last] = append(kb.InlineKeyboard[last], models.InlineKeyboardButton{
+		Text:         l.T(digestSorts[digestSortHot].Button),
+		CallbackData: digestRequest{Period: periodAll, Sort: digestSortHot}.CallbackData(),
+	})
+
+	return kb
+}
+
+// digestKeyboard returns keyboard for switching digest ranking metric and forum topics scope.
+func digestKeyboard(l localizer, chat models.Chat, req digestRequest) *models.InlineKeyboardMarkup {
+	kb := &models.InlineKeyboardMarkup{}
+	for i, mode := range digestSortOrder {
+		text := l.T(digestSorts[mode].Button)
+		if mode == req.Sort {
+			text = "• " + text
+		}
+		r := req
+		r.Sort = mode
+		button := models.InlineKeyboardButton{Text: text, CallbackData: r.CallbackData()}
+		if i%digestSortsPerRow == 0 {
+			kb.InlineKeyboard = append(kb.InlineKeyboard, nil)
+		}
+		last := len(kb.InlineKeyboard) - 1
+		kb.InlineKeyboard[last] = append(kb.InlineKeyboard[last], button)
+	}
+
+	if chat.IsForum {
+		r := req
+		r.AllTopics = !req.AllTopics
+		text := l.T("digest.all_topics")
+		if req.AllTopics {
+			text = l.T("digest.current_topic")
+		}
+		kb.InlineKeyboard = append(kb.InlineKeyboard, []models.InlineKeyboardButton{{Text: text, CallbackData: r.CallbackData()}})
+	}
+
+	return kb
+}
+
+// digestText builds digest of the chat. For forums digest is scoped to threadID topic unless all topics are requested,
+// then messages are grouped by topic. Each message block links its snippet to the message. Calendar periods are
+// taken in chat timezone.
+func (bm *BotManager) digestText(ctx context.Context, l localizer, cs db.ChatSetting, chat models.Chat, threadID int, req digestRequest) (digestHTML, error) {
+	var res digestHTML
+
+	now := time.Now()
+	period, err := parsePeriod(req.Period, now, settingLocation(cs))
+	if err != nil {
+		return res, fmt.Errorf("incorrect period=%q: %w", req.Period, err)
+	}
+
+	sort, ok := digestSorts[req.Sort]
+	if !ok {
+		return res, fmt.Errorf("incorrect sort=%q", req.Sort)
+	}
+	windowed := period.Windowed()
+
+	// period digest shows messages by reactions received within the period
+	search := chatReactionSearch(cs)
+	sortOp := sort.sortOp(now)
+	if windowed {
+		search.WithReactedInPeriod(period.From, period.To)
+		if req.Sort == digestSortReactions {
+			sortOp = db.WithPeriodReactionsSort(period.From, period.To)
+		}
+	}
+	if sort.HalfLife > 0 {
+		search.WithReactedFrom(db.HotRankingFrom(sort.HalfLife, now))
+	}
+	scoped := chat.IsForum && !req.AllTopics
+	if scoped {
+		search.WithThread(topicID(threadID))
+	}
+
+	reactions, err := bm.cr.MessageReactionsByFilters(ctx, search, db.Pager{PageSize: cs.TopN},
+		bm.cr.FullMessageReaction(), sortOp)
+	if err != nil {
+		return res, fmt.Errorf("fetch message reactions: %w", err)
+	}
+
+	bm.Printf("Retrieved %d reactions for chat %d", len(reactions), chat.ID)
+
+	messageIDs := make([]int, 0, len(reactions))
+	threadIDs := []int{threadID}
+	for _, reaction := range reactions {
+		messageIDs = append(messageIDs, reaction.MessageID)
+		if tid := messageThreadID(reaction.Message); tid != 0 {
+			threadIDs = append(threadIDs, tid)
+		}
+	}
+
+	breakdowns, err := bm.cr.MessageReactionCountsByMessages(ctx, chat.ID, messageIDs)
+	if err != nil {
+		return res, fmt.Errorf("fetch message reaction counts: %w", err)
+	}
+
+	var periodCounts map[int]int
+	if windowed {
+		if periodCounts, err = bm.cr.PeriodReactionCounts(ctx, chat.ID, messageIDs, period.From, period.To); err != nil {
+			return res, fmt.Errorf("fetch period reaction counts: %w", err)
+		}
+	}
+
+	var topics map[int]string
+	if chat.IsForum {
+		if topics, err = bm.cr.ForumTopicTitles(ctx, chat.ID, threadIDs); err != nil {
+			return res, fmt.Errorf("fetch forum topics: %w", err)
+		}
+	}
+
+	res.header = "<b>" + l.T("digest.header", l.T(sort.Title), period.title(l)) + "</b>"
+	if scoped {
+		res.header = "<b>" + l.T("digest.header_topic", l.T(sort.Title), escapeHTML(topicTitle(l, topics, threadID)), period.title(l)) + "</b>"
+	}
+
+	// line returns message block and the message to reply to if it has no permalink
+	line := func(i int, reaction db.MessageReaction) (string, int) {
+		count := 0
+		if reaction.ReactionsCount != nil {
+			count = *reaction.ReactionsCount
+		}
+
+		link, replyTo := messagePermalink(chat, reaction.MessageID, messageThreadID(reaction.Message)), 0
+		// negative IDs are messages of basic group before migration, they can't be replied to
+		if link == "" && reaction.MessageID > 0 {
+			replyTo = reaction.MessageID
+		}
+
+		s := "\n\n" + placeMark(i) + " " + htmlLink(link, messagePreview(l, reaction.Message))
+		if windowed {
+			s += "\n" + l.T("digest.period_stats", l.N("reactions", periodCounts[reaction.MessageID]), count, l.N("reactors", reaction.ReactorsCount))
+		} else {
+			s += "\n" + l.N("reactions", count) + ", " + l.N("reactors", reaction.ReactorsCount)
+		}
+		if req.Sort == digestSortScore {
+			s += ", " + l.T("digest.score", strconv.FormatFloat(reaction.Score, 'f', -1, 64))
+		}
+		if breakdown := reactionBreakdown(breakdowns[reaction.MessageID]); breakdown != "" {
+			s += " " + escapeHTML(breakdown)
+		}
+
+		return s, replyTo
+	}
+
+	if chat.IsForum && req.AllTopics {
+		for _, group := range groupByTopic(reactions) {
+			// topic title is kept in one block with its first message, so it is never split from it
+			title := "\n\n📌 <b>" + escapeHTML(topicTitle(l, topics, group.threadID)) + "</b>"
+			for j, i := range group.indexes {
+				block, replyTo := line(i, reactions[i])
+				if j == 0 {
+					block = title + block
+				}
+				res.add(block, replyTo)
+			}
+		}
+
+		return res, nil
+	}
+
+	for i, reaction := range reactions {
+		res.add(line(i, reaction))
+	}
+
+	return res, nil
+}
+
+// digestCommand sends digest for period from /digest arguments like "/digest 3d" sorted by chat default sort.
+// Invalid period gets reply with periods help.
+func (bm *BotManager) digestCommand(ctx context.Context, b *bot.Bot, l localizer, cs db.ChatSetting, m *models.Message, spec string) error {
+	req := digestRequest{Period: strings.ToLower(spec), Sort: cs.Sort}
+	if _, err := parsePeriod(req.Period, time.Now(), settingLocation(cs)); err != nil {
+		_, err = b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID:          m.Chat.ID,
+			Text:            l.E(err) + "\n\n" + l.T("period.help", digestCommand),
+			MessageThreadID: m.MessageThreadID,
+		})
+		return err
+	}
+
+	digest, err := bm.digestText(ctx, l, cs, m.Chat, m.MessageThreadID, req)
+	if err != nil {
+		return fmt.Errorf("build digest: %w", err)
+	}
+
+	return bm.sendDigest(ctx, b, l, m.Chat, m.MessageThreadID, req, digest)
+}
+
+// sendDigest sends digest to the chat topic. Long digest is posted in several messages, keyboard is attached to
+// the first one with digest header. Messages without permalinks, like in basic groups, are replied to instead.
+func (bm *BotManager) sendDigest(ctx context.Context, b *bot.Bot, l localizer, chat models.Chat, threadID int, req digestRequest, digest digestHTML) error {
+	for i, dm := range digest.Split(maxMessageLength) {
+		params := &bot.SendMessageParams{
+			ChatID:             chat.ID,
+			Text:               dm.Text,
+			ParseMode:          models.ParseModeHTML,
+			LinkPreviewOptions: disabledLinkPreview,
+			MessageThreadID:    threadID,
+			ReplyParameters:    dm.ReplyParameters(),
+		}
+		if i == 0 {
+			params.ReplyMarkup = digestKeyboard(l, chat, req)
+		}
+		if _, err := b.SendMessage(ctx, params); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// topicGroup is digest messages of one forum topic.
+type topicGroup struct {
+	threadID int
+	indexes  []int
+}
+
+// groupByTopic groups ranked reactions by forum topic keeping rank order. Topics are ordered by their best message.
+func groupByTopic(reactions []db.MessageReaction) []topicGroup {
+	var groups []topicGroup
+	index := make(map[int]int)
+	for i, reaction := range reactions {
+		tid := messageThreadID(reaction.Message)
+		gi, ok := index[tid]
+		if !ok {
+			gi = len(groups)
+			index[tid] = gi
+			groups = append(groups, topicGroup{threadID: tid})
+		}
+		groups[gi].indexes = append(groups[gi].indexes, i)
+	}
+
+	return groups
+}
+
+// messageThreadID returns forum topic or comments thread of stored message, 0 for General topic or unknown message.
+func messageThreadID(m *db.Message) int {
+	if m == nil || m.ThreadID == nil {
+		return 0
+	}
+
+	return *m.ThreadID
+}
+
+// topicID converts thread ID of telegram message into forum topic filter, General topic has no ID.
+func topicID(threadID int) *int {
+	if threadID == 0 {
+		return nil
+	}
+
+	return &threadID
+}
+
+// topicTitle returns forum topic title or its ID if title is unknown.
+func topicTitle(l localizer, topics map[int]string, threadID int) string {
+	if threadID == 0 {
+		return l.T("digest.general_topic")
+	}
+	if title, ok := topics[threadID]; ok {
+		return title
+	}
+
+	return "#" + strconv.Itoa(threadID)
+}
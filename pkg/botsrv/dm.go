@@ -0,0 +1,291 @@
+package botsrv
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"botsrv/pkg/db"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+const (
+	// dmStartPrefix is deep link /start payload of chat digest like "digest_-1001234567890".
+	dmStartPrefix = "digest_"
+	// maxDMChats limits chats offered in private chat, membership in each one is checked in telegram.
+	maxDMChats = 20
+	// memberCacheTTL is how long user membership in chat is reused without checking it in telegram.
+	memberCacheTTL = 5 * time.Minute
+)
+
+// dmDigestLink returns deep link opening chat digest in private chat with bot.
+func dmDigestLink(botUsername string, chatID int64) string {
+	return permalinkHost + botUsername + "?start=" + dmStartPrefix + strconv.FormatInt(chatID, 10)
+}
+
+// dmKeyboard prefixes callback data of digest keyboard with the chat like "dm:<chatID>:digest:day", so buttons
+// work in private chat.
+func dmKeyboard(chatID int64, kb *models.InlineKeyboardMarkup) *models.InlineKeyboardMarkup {
+	prefix := patternDM + strconv.FormatInt(chatID, 10) + ":"
+	for _, row := range kb.InlineKeyboard {
+		for i := range row {
+			if row[i].CallbackData != "" {
+				row[i].CallbackData = prefix + row[i].CallbackData
+			}
+		}
+	}
+
+	return kb
+}
+
+// parseDMCallback parses callback data "dm:<chatID>[:<digest callback data>]". Empty digest data means chat is
+// chosen and digest period should be asked.
+func parseDMCallback(data string) (int64, string, error) {
+	id, rest, _ := strings.Cut(strings.TrimPrefix(data, patternDM), ":")
+	chatID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return 0, "", fmt.Errorf("invalid chat id=%q", id)
+	}
+
+	return chatID, rest, nil
+}
+
+// botUsername returns bot username, it is requested from telegram once.
+func (bm *BotManager) botUsername(ctx context.Context, b *bot.Bot) (string, error) {
+	bm.mu.Lock()
+	defer bm.mu.Unlock()
+
+	if bm.username == "" {
+		me, err := b.GetMe(ctx)
+		if err != nil {
+			return "", fmt.Errorf("get bot info: %w", err)
+		}
+		bm.username = me.Username
+	}
+
+	return bm.username, nil
+}
+
+// isMember checks user membership in chat in telegram, result is cached for memberCacheTTL.
+func (bm *BotManager) isMember(ctx context.Context, b *bot.Bot, chatID, userID int64) (bool, error) {
+	now := time.Now()
+	key := strconv.FormatInt(chatID, 10) + ":" + strconv.FormatInt(userID, 10)
+	if ok, cached := bm.members.Get(key, now); cached {
+		return ok, nil
+	}
+
+	cm, err := b.GetChatMember(ctx, &bot.GetChatMemberParams{ChatID: chatID, UserID: userID})
+	switch {
+	case errors.Is(err, bot.ErrorBadRequest):
+		// user was never in the chat
+	case err != nil:
+		return false, fmt.Errorf("get chat member: %w", err)
+	}
+
+	ok := err == nil && isChatMember(*cm)
+	bm.members.Set(key, ok, now)
+
+	return ok, nil
+}
+
+// memberChat returns tracked chat if bot and the user are its members, nil otherwise. User membership is checked
+// in telegram, so left users can't see chat data.
+func (bm *BotManager) memberChat(ctx context.Context, b *bot.Bot, chatID, userID int64) (*db.Chat, error) {
+	chat, err := bm.cr.ChatByID(ctx, chatID)
+	if err != nil {
+		return nil, fmt.Errorf("fetch chat: %w", err)
+	} else if chat == nil || chat.LeftAt != nil {
+		return nil, nil
+	}
+
+	if ok, err := bm.isMember(ctx, b, chatID, userID); err != nil || !ok {
+		return nil, err
+	}
+
+	return chat, nil
+}
+
+// memberChats returns up to limit chats shared by the user with bot, membership is checked until limit is reached.
+func (bm *BotManager) memberChats(ctx context.Context, b *bot.Bot, userID int64, limit int) ([]db.Chat, error) {
+	list, err := bm.cr.UserChats(ctx, userID, db.Pager{PageSize: maxDMChats})
+	if err != nil {
+		return nil, fmt.Errorf("fetch user chats: %w", err)
+	}
+
+	res := list[:0]
+	for _, c := range list {
+		if len(res) == limit {
+			break
+		}
+
+		chat, err := bm.memberChat(ctx, b, c.ID, userID)
+		if err != nil {
+			return nil, err
+		} else if chat != nil {
+			res = append(res, *chat)
+		}
+	}
+
+	return res, nil
+}
+
+// chatTitle returns chat title or its ID for chats without title.
+func chatTitle(c models.Chat) string {
+	if c.Title != "" {
+		return c.Title
+	}
+
+	return "#" + strconv.FormatInt(c.ID, 10)
+}
+
+// dmChatsParams returns message with chats shared by the user with bot to choose digest of.
+func (bm *BotManager) dmChatsParams(ctx context.Context, b *bot.Bot, l localizer, userID int64) (*bot.SendMessageParams, error) {
+	chats, err := bm.memberChats(ctx, b, userID, maxDMChats)
+	if err != nil {
+		return nil, err
+	}
+
+	params := &bot.SendMessageParams{ChatID: userID, Text: l.T("dm.no_chats")}
+	if len(chats) == 0 {
+		return params, nil
+	}
+
+	kb := &models.InlineKeyboardMarkup{}
+	for _, c := range chats {
+		kb.InlineKeyboard = append(kb.InlineKeyboard, []models.InlineKeyboardButton{{
+			Text:         chatTitle(chatModel(&c)),
+			CallbackData: patternDM + strconv.FormatInt(c.ID, 10),
+		}})
+	}
+	params.Text, params.ReplyMarkup = l.T("dm.choose_chat"), kb
+
+	return params, nil
+}
+
+// dmPeriodParams returns message asking digest period of the chat in private chat.
+func (bm *BotManager) dmPeriodParams(ctx context.Context, u *models.User, chat *db.Chat) (*bot.SendMessageParams, error) {
+	cs, err := bm.chatSetting(ctx, chat.ID)
+	if err != nil {
+		return nil, err
+	}
+	l := chatLocalizer(cs, u)
+
+	return &bot.SendMessageParams{
+		ChatID:      u.ID,
+		Text:        l.T("dm.choose_period", chatTitle(chatModel(chat))),
+		ReplyMarkup: dmKeyboard(chat.ID, digestPeriodKeyboard(l, cs)),
+	}, nil
+}
+
+// StartHandler greets users. In private chat deep link payload "digest_<chatID>" opens digest of the chat after
+// membership check, without payload user chooses one of the chats shared with bot.
+func (bm *BotManager) StartHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	m := update.Message
+	if m == nil {
+		return
+	}
+
+	cs, err := bm.chatSetting(ctx, m.Chat.ID)
+	if err != nil {
+		bm.Errorf("%v", err)
+		return
+	}
+	l := chatLocalizer(cs, m.From)
+
+	params := &bot.SendMessageParams{ChatID: m.Chat.ID, MessageThreadID: m.MessageThreadID, Text: l.T("start.help")}
+	if m.Chat.Type == models.ChatTypePrivate && m.From != nil {
+		payload := strings.TrimSpace(strings.TrimPrefix(m.Text, startCommand))
+		chatID, perr := strconv.ParseInt(strings.TrimPrefix(payload, dmStartPrefix), 10, 64)
+
+		switch {
+		case strings.HasPrefix(payload, dmStartPrefix) && perr == nil:
+			chat, err := bm.memberChat(ctx, b, chatID, m.From.ID)
+			if err != nil {
+				bm.Errorf("%v", err)
+				return
+			} else if chat == nil {
+				params.Text = l.T("dm.not_member")
+				break
+			}
+
+			if params, err = bm.dmPeriodParams(ctx, m.From, chat); err != nil {
+				bm.Errorf("%v", err)
+				return
+			}
+		default:
+			if params, err = bm.dmChatsParams(ctx, b, l, m.From.ID); err != nil {
+				bm.Errorf("%v", err)
+				return
+			}
+			params.Text = l.T("start.help") + "\n\n" + params.Text
+		}
+	}
+
+	if _, err = b.SendMessage(ctx, params); err != nil {
+		bm.Errorf("%v", err)
+		return
+	}
+}
+
+// DMCallbackHandler sends digest of chosen chat in private chat. Membership is checked on each request.
+func (bm *BotManager) DMCallbackHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	cq := update.CallbackQuery
+	if cq == nil || cq.Message.Message == nil {
+		return
+	}
+	defer bm.answerCallback(ctx, b, &bot.AnswerCallbackQueryParams{CallbackQueryID: cq.ID})
+
+	msg := cq.Message.Message
+	chatID, data, err := parseDMCallback(cq.Data)
+	if err != nil {
+		bm.Errorf("%v", err)
+		return
+	}
+
+	chat, err := bm.memberChat(ctx, b, chatID, cq.From.ID)
+	if err != nil {
+		bm.Errorf("%v", err)
+		return
+	}
+
+	cs, err := bm.chatSetting(ctx, chatID)
+	if err != nil {
+		bm.Errorf("%v", err)
+		return
+	}
+	l := chatLocalizer(cs, &cq.From)
+
+	params := &bot.EditMessageTextParams{ChatID: msg.Chat.ID, MessageID: msg.ID}
+	switch {
+	case chat == nil:
+		params.Text = l.T("dm.not_member")
+	case data == "":
+		params.Text = l.T("dm.choose_period", chatTitle(chatModel(chat)))
+		params.ReplyMarkup = dmKeyboard(chatID, digestPeriodKeyboard(l, cs))
+	default:
+		// forum digest in private chat always covers all topics
+		req := parseDigestRequest(data)
+		req.AllTopics = true
+
+		digest, err := bm.digestText(ctx, l, cs, chatModel(chat), 0, req)
+		if err != nil {
+			bm.Errorf("Failed to build digest: %v", err)
+			return
+		}
+
+		title := "💬 " + escapeHTML(chatTitle(chatModel(chat))) + "\n"
+		params.Text = title + digest.Truncate(l, maxMessageLength-textLength(title))
+		params.ParseMode, params.LinkPreviewOptions = models.ParseModeHTML, disabledLinkPreview
+		params.ReplyMarkup = dmKeyboard(chatID, digestKeyboard(l, models.Chat{}, req))
+	}
+
+	if _, err = b.EditMessageText(ctx, params); err != nil {
+		bm.Errorf("%v", err)
+		return
+	}
+}
@@ -4,19 +4,24 @@ import (
 	"botsrv/pkg/db"
 	"botsrv/pkg/embedlog"
 	"context"
-	"fmt"
-	"strconv"
 	"strings"
+	"sync"
 	"time"
 
-	"github.com/go-pg/pg/v10"
 	"github.com/go-telegram/bot"
 	"github.com/go-telegram/bot/models"
 )
 
 const (
-	startCommand  = "/start"
-	digestCommand = "/digest"
+	startCommand    = "/start"
+	digestCommand   = "/digest"
+	scheduleCommand = "/schedule"
+	settingsCommand = "/settings"
+	authorsCommand  = "/topauthors"
+	reactorsCommand = "/topreactors"
+	statsCommand    = "/stats"
+	chartCommand    = "/chart"
+	heatmapCommand  = "/heatmap"
 
 	patternDigestHour  = "digest:hour"
 	patternDigestDay   = "digest:day"
@@ -24,104 +29,153 @@ const (
 	patternDigestMonth = "digest:month"
 	patternDigestAll   = "digest:all"
 	paternDigest       = "digest:"
+	patternSettings    = "settings:"
+	patternAuthors     = "authors:"
+	patternDM          = "dm:"
+
+	defaultWorkers = 8
 )
 
 type Config struct {
 	Token string
+	// ChatCleanupDelay is the grace period after bot removal before chat data is deleted.
+	ChatCleanupDelay time.Duration
+	// Workers is the number of updates handled concurrently.
+	Workers int
+	AggregatorConfig
 }
 
 type BotManager struct {
 	embedlog.Logger
 	dbo db.DB
 	cr  db.CommonRepo
+	ra  *ReactionAggregator
+	cfg Config
+
+	mu       sync.Mutex
+	username string
+	inline   *ttlCache[[]models.InlineQueryResult]
+	members  *ttlCache[bool]
+	// registered is IDs of chats known to be in registry, so updates don't hit DB for each of them.
+	registered sync.Map
 }
 
-func NewBotManager(logger embedlog.Logger, dbo db.DB) *BotManager {
+func NewBotManager(logger embedlog.Logger, dbo db.DB, ra *ReactionAggregator, cfg Config) *BotManager {
+	if cfg.ChatCleanupDelay <= 0 {
+		cfg.ChatCleanupDelay = defaultChatCleanupDelay
+	}
+	if cfg.Workers <= 0 {
+		cfg.Workers = defaultWorkers
+	}
+
 	return &BotManager{
-		Logger: logger,
-		dbo:    dbo,
-		cr:     db.NewCommonRepo(dbo),
+		Logger:  logger,
+		dbo:     dbo,
+		cr:      db.NewCommonRepo(dbo),
+		ra:      ra,
+		cfg:     cfg,
+		inline:  newTTLCache[[]models.InlineQueryResult](inlineCacheTTL),
+		members: newTTLCache[bool](memberCacheTTL),
+	}
+}
+
+// BotOptions returns options running handlers in bot workers instead of separate goroutines, so bot Start returns
+// after all handlers are finished and no reactions are buffered after that.
+func (bm *BotManager) BotOptions() []bot.Option {
+	return []bot.Option{
+		bot.WithDefaultHandler(bm.DefaultHandler),
+		bot.WithWorkers(bm.cfg.Workers),
+		bot.WithNotAsyncHandlers(),
 	}
 }
 
 func (bm *BotManager) RegisterBotHandlers(b *bot.Bot) {
 	b.RegisterHandler(bot.HandlerTypeMessageText, startCommand, bot.MatchTypePrefix, bm.StartHandler)
 	b.RegisterHandler(bot.HandlerTypeMessageText, digestCommand, bot.MatchTypePrefix, bm.DigestHandler)
+	b.RegisterHandler(bot.HandlerTypeMessageText, scheduleCommand, bot.MatchTypePrefix, bm.ScheduleHandler)
+	b.RegisterHandler(bot.HandlerTypeMessageText, settingsCommand, bot.MatchTypePrefix, bm.SettingsHandler)
+	b.RegisterHandler(bot.HandlerTypeMessageText, authorsCommand, bot.MatchTypePrefix, bm.TopAuthorsHandler)
+	b.RegisterHandler(bot.HandlerTypeMessageText, reactorsCommand, bot.MatchTypePrefix, bm.TopReactorsHandler)
+	b.RegisterHandler(bot.HandlerTypeMessageText, statsCommand, bot.MatchTypePrefix, bm.StatsHandler)
+	b.RegisterHandler(bot.HandlerTypeMessageText, chartCommand, bot.MatchTypePrefix, bm.ChartHandler)
+	b.RegisterHandler(bot.HandlerTypeMessageText, heatmapCommand, bot.MatchTypePrefix, bm.HeatmapHandler)
 	b.RegisterHandler(bot.HandlerTypeCallbackQueryData, paternDigest, bot.MatchTypePrefix, bm.DigestCallbackHandler)
+	b.RegisterHandler(bot.HandlerTypeCallbackQueryData, patternSettings, bot.MatchTypePrefix, bm.SettingsCallbackHandler)
+	b.RegisterHandler(bot.HandlerTypeCallbackQueryData, patternAuthors, bot.MatchTypePrefix, bm.AuthorsCallbackHandler)
+	b.RegisterHandler(bot.HandlerTypeCallbackQueryData, patternDM, bot.MatchTypePrefix, bm.DMCallbackHandler)
+	b.RegisterHandlerMatchFunc(isInlineQuery, bm.InlineQueryHandler)
 }
 
 func (bm *BotManager) DefaultHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
-	if update.MessageReaction != nil {
-		if err := bm.dbo.RunInTransaction(ctx, func(tx *pg.Tx) error {
-			crTx := bm.cr.WithTransaction(tx)
-			mr, err := crTx.OneMessageReaction(ctx, &db.MessageReactionSearch{
-				MessageID: &update.MessageReaction.MessageID,
-				ChatID:    &update.MessageReaction.Chat.ID,
-			})
-			if err != nil {
-				return err
-			}
-			if mr == nil {
-				bm.Printf("Creating new message reaction for message ID: %d", update.MessageReaction.MessageID)
-				_, err = crTx.AddMessageReaction(ctx, &db.MessageReaction{
-					MessageID:      update.MessageReaction.MessageID,
-					ChatID:         update.MessageReaction.Chat.ID,
-					ReactionsCount: pointer(1),
-				})
-				if err != nil {
-					return err
-				}
-			} else {
-				*mr.ReactionsCount += len(update.MessageReaction.NewReaction) - len(update.MessageReaction.OldReaction)
-				_, err = crTx.UpdateMessageReaction(ctx, mr)
-				if err != nil {
-					return err
-				}
-			}
-			return nil
-		}); err != nil {
-			bm.Errorf("%v", err)
-			return
-		}
+	var err error
+	switch {
+	case update.Message != nil:
+		err = bm.saveMessage(ctx, update.Message)
+	case update.ChannelPost != nil:
+		err = bm.saveMessage(ctx, update.ChannelPost)
+	case update.MessageReaction != nil:
+		err = bm.saveMessageReaction(ctx, update.MessageReaction)
+	case update.MessageReactionCount != nil:
+		err = bm.saveMessageReactionCount(ctx, update.MessageReactionCount)
+	case update.MyChatMember != nil:
+		err = bm.saveMyChatMember(ctx, update.MyChatMember)
+	case update.ChatMember != nil:
+		err = bm.saveChatInfo(ctx, update.ChatMember.Chat)
+	}
+
+	if err != nil {
+		bm.Errorf("%v", err)
+		return
 	}
 }
 
-func (bm *BotManager) StartHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+func (bm *BotManager) DigestHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
 	if update.Message == nil {
 		return
 	}
-	_, err := b.SendMessage(ctx, &bot.SendMessageParams{
-		ChatID: update.Message.Chat.ID,
-		Text:   "start",
-	})
+	cs, err := bm.chatSetting(ctx, update.Message.Chat.ID)
 	if err != nil {
 		bm.Errorf("%v", err)
 		return
 	}
-}
+	l := chatLocalizer(cs, update.Message.From)
 
-func (bm *BotManager) DigestHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
-	if update.Message == nil {
+	// private chat has no digest of its own, user chooses one of shared chats
+	if update.Message.Chat.Type == models.ChatTypePrivate && update.Message.From != nil {
+		params, err := bm.dmChatsParams(ctx, b, l, update.Message.From.ID)
+		if err != nil {
+			bm.Errorf("%v", err)
+			return
+		}
+		if _, err = b.SendMessage(ctx, params); err != nil {
+			bm.Errorf("%v", err)
+		}
+		return
+	}
+
+	if args := strings.Fields(update.Message.Text)[1:]; len(args) > 0 {
+		if err = bm.digestCommand(ctx, b, l, cs, update.Message, strings.Join(args, "")); err != nil {
+			bm.Errorf("%v", err)
+		}
 		return
 	}
-	kb := &models.InlineKeyboardMarkup{
-		InlineKeyboard: [][]models.InlineKeyboardButton{
-			{
-				{Text: "За час", CallbackData: patternDigestHour},
-				{Text: "За день", CallbackData: patternDigestDay},
-			},
-			{
-				{Text: "За неделю", CallbackData: patternDigestWeek},
-				{Text: "За месяц", CallbackData: patternDigestMonth},
-			},
-			{
-				{Text: "За всё время", CallbackData: patternDigestAll},
-			},
-		},
-	}
-	_, err := b.SendMessage(ctx, &bot.SendMessageParams{
+
+	kb := digestPeriodKeyboard(l, cs)
+	if isTrackedChat(update.Message.Chat) {
+		username, err := bm.botUsername(ctx, b)
+		if err != nil {
+			bm.Errorf("%v", err)
+			return
+		}
+		kb.InlineKeyboard = append(kb.InlineKeyboard, []models.InlineKeyboardButton{{
+			Text: l.T("digest.dm"),
+			URL:  dmDigestLink(username, update.Message.Chat.ID),
+		}})
+	}
+
+	_, err = b.SendMessage(ctx, &bot.SendMessageParams{
 		ChatID:          update.Message.Chat.ID,
-		Text:            "Выберите интервал для дайджеста:",
+		Text:            l.T("digest.choose"),
 		ReplyMarkup:     kb,
 		MessageThreadID: update.Message.MessageThreadID,
 	})
@@ -131,102 +185,50 @@ func (bm *BotManager) DigestHandler(ctx context.Context, b *bot.Bot, update *mod
 	}
 }
 
-type ReactionsPeriod struct {
-	Title  string
-	Period time.Duration
-}
-
-var reactionPeriods = map[string]ReactionsPeriod{
-	patternDigestHour: {
-		Title:  "час",
-		Period: 1 * time.Hour,
-	},
-	patternDigestDay: {
-		Title:  "день",
-		Period: 24 * time.Hour,
-	},
-	patternDigestWeek: {
-		Title:  "неделю",
-		Period: 24 * 7 * time.Hour,
-	},
-	patternDigestMonth: {
-		Title:  "месяц",
-		Period: 24 * 30 * time.Hour,
-	},
-	patternDigestAll: {
-		Title: "всё время",
-	},
-}
-
 func (bm *BotManager) DigestCallbackHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
-	if update.CallbackQuery == nil || update.CallbackQuery.Data == "" {
+	cq := update.CallbackQuery
+	if cq == nil || cq.Message.Message == nil || !strings.HasPrefix(cq.Data, "digest:") {
 		return
 	}
+	defer bm.answerCallback(ctx, b, &bot.AnswerCallbackQueryParams{CallbackQueryID: cq.ID})
 
-	if !strings.HasPrefix(update.CallbackQuery.Data, "digest:") {
-		return
-	}
-	periodPatern := update.CallbackQuery.Data
-	bm.Printf("Processing digest callback with period: %s", periodPatern)
-	chat := update.CallbackQuery.Message.Message.Chat
-
-	chatID := chat.ID
-	messageID := update.CallbackQuery.Message.Message.ID
-
-	pageSize := 10
-
-	now := time.Now()
-	var period time.Time
+	req := parseDigestRequest(cq.Data)
+	bm.Printf("Processing digest callback with period: %s, sort: %s, all topics: %v", req.Period, req.Sort, req.AllTopics)
 
-	pattern, ok := reactionPeriods[periodPatern]
-	if !ok {
-		bm.Errorf("incorrect periodPatern")
+	msg := cq.Message.Message
+	cs, err := bm.chatSetting(ctx, msg.Chat.ID)
+	if err != nil {
+		bm.Errorf("%v", err)
 		return
 	}
+	l := chatLocalizer(cs, &cq.From)
 
-	period = now.Add(-pattern.Period)
-	if periodPatern == patternDigestAll {
-		period = time.Unix(0, 0)
-	}
-
-	reactions, err := bm.cr.MessageReactionsByFilters(ctx, &db.MessageReactionSearch{
-		ReactionsPeriod: &period,
-		ChatID:          &chat.ID,
-	}, db.Pager{PageSize: pageSize},
-		db.WithSort(db.NewSortField(db.Columns.MessageReaction.ReactionsCount, true)))
+	digest, err := bm.digestText(ctx, l, cs, msg.Chat, msg.MessageThreadID, req)
 	if err != nil {
-		bm.Errorf("Failed to fetch message reactions: %v", err)
+		bm.Errorf("Failed to build digest: %v", err)
 		return
 	}
 
-	bm.Printf("Retrieved %d reactions for chat %d", len(reactions), chat.ID)
-
-	res := fmt.Sprintf("Топ сообщений по реакциям в чате за %s:", pattern.Title)
-	for _, reaction := range reactions {
-		var link string
-
-		chatIDStr := strconv.FormatInt(-chat.ID-1000000000000, 10)
-		switch update.CallbackQuery.Message.Message.Chat.Type {
-		case models.ChatTypeGroup:
-			link = fmt.Sprintf("https://t.me/%s/%d", chat.Username, reaction.MessageID)
-		case models.ChatTypeSupergroup:
-			link = fmt.Sprintf("https://t.me/c/%s/%d", chatIDStr, reaction.MessageID)
-			if thread := update.CallbackQuery.Message.Message.MessageThreadID; thread != 0 {
-				link += fmt.Sprintf("?thread=%d", thread)
-			}
+	// edited message can't reply, so digest with replies is sent anew replacing the menu
+	if digest.HasReplies() {
+		if err = bm.sendDigest(ctx, b, l, msg.Chat, msg.MessageThreadID, req, digest); err != nil {
+			bm.Errorf("%v", err)
+			return
 		}
-
-		count := 0
-		if reaction.ReactionsCount != nil {
-			count = *reaction.ReactionsCount
+		if _, err = b.DeleteMessage(ctx, &bot.DeleteMessageParams{ChatID: msg.Chat.ID, MessageID: msg.ID}); err != nil {
+			bm.Errorf("%v", err)
 		}
-		res += fmt.Sprintf("\nРеакций: %d Ссылка: %s", count, link)
+		return
 	}
 
+	// edited digest stays in one message, so long digest is truncated
 	_, err = b.EditMessageText(ctx, &bot.EditMessageTextParams{
-		ChatID:    chatID,
-		MessageID: messageID,
-		Text:      res,
+		ChatID:             msg.Chat.ID,
+		MessageID:          msg.ID,
+		Text:               digest.Truncate(l, maxMessageLength),
+		ParseMode:          models.ParseModeHTML,
+		LinkPreviewOptions: disabledLinkPreview,
+		ReplyMarkup:        digestKeyboard(l, msg.Chat, req),
 	})
 	if err != nil {
 		bm.Errorf("%v", err)
@@ -234,4 +236,11 @@ func (bm *BotManager) DigestCallbackHandler(ctx context.Context, b *bot.Bot, upd
 	}
 }
 
+// answerCallback stops loading animation of pressed button. Handlers defer it, so params may be changed before answer.
+func (bm *BotManager) answerCallback(ctx context.Context, b *bot.Bot, params *bot.AnswerCallbackQueryParams) {
+	if _, err := b.AnswerCallbackQuery(ctx, params); err != nil {
+		bm.Errorf("answer callback query: %v", err)
+	}
+}
+
 func pointer[T any](in T) *T { return &in }
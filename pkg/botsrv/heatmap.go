@@ -0,0 +1,117 @@
+package botsrv
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"botsrv/pkg/db"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// heatmapLevels is heatmap cells from no reactions to the max count.
+var heatmapLevels = []rune("·░▒▓█")
+
+// heatmapLevel returns cell of count scaled to maxCount, only hours without reactions are empty.
+func heatmapLevel(count, maxCount int) rune {
+	if count <= 0 || maxCount <= 0 {
+		return heatmapLevels[0]
+	}
+
+	top := len(heatmapLevels) - 1
+	return heatmapLevels[(count*top+maxCount-1)/maxCount]
+}
+
+// heatmapGrid renders reactions by weekday and hour as monospace grid: a row per weekday, a column per hour.
+func heatmapGrid(l localizer, cells [7][24]int, maxCount int) string {
+	hours := []rune(strings.Repeat(" ", 24))
+	for h := 0; h < 24; h += 6 {
+		copy(hours[h:], []rune(fmt.Sprint(h)))
+	}
+
+	rows := []string{"   " + string(hours)}
+	for d, weekday := range strings.Fields(l.T("heatmap.weekdays")) {
+		row := make([]rune, 0, 24)
+		for _, count := range cells[d] {
+			row = append(row, heatmapLevel(count, maxCount))
+		}
+		rows = append(rows, weekday+" "+string(row))
+	}
+
+	return strings.Join(rows, "\n")
+}
+
+// heatmapText builds chat activity heatmap: reactions left in period by weekday and hour in chat timezone.
+func (bm *BotManager) heatmapText(ctx context.Context, l localizer, cs db.ChatSetting, spec string) (string, error) {
+	loc := settingLocation(cs)
+	period, err := parsePeriod(spec, time.Now(), loc)
+	if err != nil {
+		return "", fmt.Errorf("incorrect period=%q: %w", spec, err)
+	}
+
+	list, err := bm.cr.ChatHourOfWeekReactions(ctx, cs.ChatID, period.From, period.To, loc)
+	if err != nil {
+		return "", fmt.Errorf("fetch heatmap: %w", err)
+	}
+
+	res := "<b>" + escapeHTML(l.T("heatmap.header", period.title(l), loc.String())) + "</b>\n\n"
+	if len(list) == 0 {
+		return res + l.T("no_reactions"), nil
+	}
+
+	var (
+		cells [7][24]int
+		peak  db.HourReactions
+	)
+	for _, hr := range list {
+		cells[hr.Weekday-1][hr.Hour] = hr.ReactionsCount
+		if hr.ReactionsCount > peak.ReactionsCount {
+			peak = hr
+		}
+	}
+
+	weekdays := strings.Fields(l.T("heatmap.weekdays"))
+	return res + "<pre>" + escapeHTML(heatmapGrid(l, cells, peak.ReactionsCount)) + "</pre>\n" +
+		escapeHTML(l.T("heatmap.peak", weekdays[peak.Weekday-1], peak.Hour, l.N("reactions", peak.ReactionsCount))) + "\n" +
+		escapeHTML(l.T("heatmap.scale", string(heatmapLevels[0]), string(heatmapLevels[len(heatmapLevels)-1]))), nil
+}
+
+// HeatmapHandler sends activity heatmap for period from command arguments like "/heatmap month",
+// chat default period is used without arguments.
+func (bm *BotManager) HeatmapHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	m := update.Message
+	if m == nil || !isTrackedChat(m.Chat) {
+		return
+	}
+
+	cs, err := bm.chatSetting(ctx, m.Chat.ID)
+	if err != nil {
+		bm.Errorf("%v", err)
+		return
+	}
+	l := chatLocalizer(cs, m.From)
+
+	spec := cs.Period
+	if args := strings.Fields(m.Text)[1:]; len(args) > 0 {
+		spec = strings.ToLower(strings.Join(args, ""))
+	}
+
+	params := &bot.SendMessageParams{ChatID: m.Chat.ID, MessageThreadID: m.MessageThreadID}
+	if _, err = parsePeriod(spec, time.Now(), settingLocation(cs)); err != nil {
+		params.Text = l.E(err) + "\n\n" + l.T("period.help", heatmapCommand)
+	} else {
+		if params.Text, err = bm.heatmapText(ctx, l, cs, spec); err != nil {
+			bm.Errorf("Failed to build heatmap: %v", err)
+			return
+		}
+		params.ParseMode = models.ParseModeHTML
+	}
+
+	if _, err = b.SendMessage(ctx, params); err != nil {
+		bm.Errorf("%v", err)
+		return
+	}
+}
@@ -0,0 +1,500 @@
+package botsrv
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"botsrv/pkg/db"
+
+	"github.com/go-telegram/bot/models"
+)
+
+const (
+	langRu = "ru"
+	langEn = "en"
+
+	// defaultLanguage is used when neither chat nor requester language is known.
+	defaultLanguage = langRu
+	// fallbackLanguage is used for requesters with language missing in catalog.
+	fallbackLanguage = langEn
+)
+
+// catalog is bot texts by language and key. Texts are fmt formats.
+var catalog = map[string]map[string]string{
+	langRu: {
+		"period.hour":         "час",
+		"period.day":          "день",
+		"period.week":         "неделю",
+		"period.month":        "месяц",
+		"period.all":          "всё время",
+		"period.hour.button":  "За час",
+		"period.day.button":   "За день",
+		"period.week.button":  "За неделю",
+		"period.month.button": "За месяц",
+		"period.all.button":   "За всё время",
+		"period.today":        "сегодня",
+		"period.yesterday":    "вчера",
+		"period.this_week":    "эту неделю",
+		"period.last_week":    "прошлую неделю",
+		"period.this_month":   "этот месяц",
+		"period.last_month":   "прошлый месяц",
+		"period.date":         "02.01.2006",
+		"period.range":        "%s — %s",
+		"period.help": "Периоды:\n" +
+			"%[1]s 12h, %[1]s 3d, %[1]s 2w — за последние часы, дни или недели\n" +
+			"%[1]s today, %[1]s yesterday — за сегодня или вчера\n" +
+			"%[1]s this-week, %[1]s last-week — за эту или прошлую неделю\n" +
+			"%[1]s this-month, %[1]s last-month — за этот или прошлый месяц\n" +
+			"%[1]s 2025-09-01..2025-09-30 — за даты\n\n" +
+			"Календарные периоды считаются в часовом поясе чата, неделя начинается с понедельника.",
+		"period.err.empty":    "не указан период",
+		"period.err.too_long": "период «%s» должен быть положительным и не длиннее %d дней",
+		"period.err.unknown":  "неизвестный период «%s»",
+		"period.err.date":     "неверная дата «%s»",
+		"period.err.reversed": "период «%s» заканчивается раньше, чем начинается",
+		"period.err.future":   "период «%s» начинается в будущем",
+
+		"sort.reactions":        "по реакциям",
+		"sort.reactions.button": "По реакциям",
+		"sort.reactors":         "по числу участников",
+		"sort.reactors.button":  "По участникам",
+		"sort.score":            "по рейтингу",
+		"sort.score.button":     "По рейтингу",
+		"sort.hot":              "в тренде",
+		"sort.hot.button":       "🔥 В тренде",
+
+		"digest.choose":        "Выберите интервал для дайджеста:",
+		"digest.dm":            "📬 Получить в личных сообщениях",
+		"digest.all_topics":    "Все темы",
+		"digest.current_topic": "Текущая тема",
+		"digest.general_topic": "Общая",
+		"digest.header":        "Топ сообщений %s в чате за %s:",
+		"digest.header_topic":  "Топ сообщений %s в теме «%s» за %s:",
+		"digest.period_stats":  "%s за период (всего %d), %s",
+		"digest.score":         "рейтинг %s",
+
+		"message.default":     "Сообщение",
+		"media.photo":         "фото",
+		"media.video":         "видео",
+		"media.animation":     "GIF",
+		"media.audio":         "аудио",
+		"media.voice":         "голосовое",
+		"media.video_note":    "кружок",
+		"media.document":      "файл",
+		"media.sticker":       "стикер",
+		"media.poll":          "опрос",
+		"media.location":      "геопозиция",
+		"media.contact":       "контакт",
+		"media.dice":          "кубик",
+		"media.story":         "история",
+		"error":               "Ошибка: %s",
+		"no_reactions":        "За этот период реакций нет.",
+		"schedule.admin_only": "Изменять расписание могут только администраторы чата.",
+		"language.auto":       "авто",
+		"schedule.current":    "Текущие расписания:",
+		"schedule.delete":     "Укажите номер расписания: /schedule delete <id>",
+		"schedule.not_found":  "Расписание не найдено.",
+		"schedule.deleted":    "Расписание удалено.",
+		"schedule.added":      "Расписание добавлено: %s",
+		"schedule.item":       "#%d «%s» (%s) за %s %s",
+		"schedule.quiet":      ", тихие часы %s-%s",
+		"schedule.next":       ", следующий %s",
+		"schedule.help": "Расписание дайджестов:\n" +
+			"/schedule daily 21:00 — каждый день\n" +
+			"/schedule weekly mon 10:00 — раз в неделю\n" +
+			"/schedule cron 0 21 * * 1-5 — cron-выражение\n" +
+			"/schedule delete <id> — удалить расписание\n\n" +
+			"Параметры: tz=Europe/Moscow period=day sort=reactions quiet=23:00-08:00\n" +
+			"Дайджест публикуется в теме, где создано расписание.",
+		"schedule.err.empty":            "не указано расписание",
+		"schedule.err.unknown":          "неизвестное расписание «%s»",
+		"schedule.err.time_required":    "нужно указать время",
+		"schedule.err.weekday_required": "нужно указать день недели и время",
+		"schedule.err.weekday":          "неверный день недели «%s»",
+		"schedule.err.time":             "неверное время «%s»",
+		"schedule.err.cron_fields":      "cron-выражение должно состоять из 5 полей",
+		"schedule.err.cron":             "неверное cron-выражение «%s»",
+		"schedule.err.never":            "cron-выражение «%s» никогда не срабатывает",
+		"schedule.err.option":           "неверный параметр «%s»",
+		"schedule.err.unknown_option":   "неизвестный параметр «%s»",
+		"schedule.err.quiet":            "неверные тихие часы «%s»",
+		"schedule.err.timezone":         "неверный часовой пояс «%s»",
+		"schedule.err.sort":             "неверная сортировка «%s»",
+
+		"authors.header": "Топ авторов за %s:",
+		"authors.page":   "стр. %d из %d",
+		"authors.prev":   "« Назад",
+		"authors.next":   "Далее »",
+
+		"reactors.header":    "Самые щедрые за %s:",
+		"reactors.favorite":  "любимая %s",
+		"reactors.hint":      "Скрыть себя из рейтинга: %[1]s optout, вернуть: %[1]s optin",
+		"reactors.opted_out": "Вы скрыты из рейтинга щедрых.",
+		"reactors.opted_in":  "Вы снова участвуете в рейтинге щедрых.",
+
+		"stats.header":      "Статистика за %s:",
+		"stats.reactions":   "Реакций: %d",
+		"stats.trend":       "%s %d%% к предыдущему периоду",
+		"stats.trend_new":   "в предыдущем периоде реакций не было",
+		"stats.messages":    "Сообщений с реакциями: %d",
+		"stats.per_message": "На сообщение: в среднем %.1f, медиана %.1f",
+		"stats.top":         "Популярные: %s",
+		"stats.busiest_day": "Самый активный день: %s, %s",
+
+		"chart.header":  "Реакции за %s",
+		"chart.summary": "Всего: %d, пик: %d (%s)",
+
+		"heatmap.header":   "Активность по часам за %s (%s):",
+		"heatmap.weekdays": "Пн Вт Ср Чт Пт Сб Вс",
+		"heatmap.peak":     "Пик: %s %02d:00, %s",
+		"heatmap.scale":    "%s — нет реакций, %s — пик",
+
+		"start.help": "Я собираю дайджесты популярных по реакциям сообщений. Добавьте меня в группу и используйте /digest, " +
+			"/topauthors, /topreactors, /stats, /chart и /heatmap.",
+		"dm.choose_chat":   "Выберите чат, дайджест которого хотите получить:",
+		"dm.choose_period": "Дайджест чата «%s» за:",
+		"dm.no_chats":      "Не нашёл общих с вами чатов. Напишите или поставьте реакцию в чате с ботом и попробуйте снова.",
+		"dm.not_member":    "Дайджест доступен только участникам чата.",
+
+		"inline.description": "Дайджест за %s",
+
+		"settings.title": "Настройки дайджеста:\nРазмер топа: %d\nПериод по умолчанию: %s\nСортировка: %s\nЯзык: %s\n" +
+			"Часовой пояс: %s\nИсключены: %s, %s",
+		"settings.help": "Команды настроек:\n" +
+			"/settings tz Europe/Moscow — часовой пояс\n" +
+			"/settings weight 👎 -1 — вес реакции в сортировке по рейтингу, по умолчанию 1\n" +
+			"/settings exclude user — ответом на сообщение, исключить автора из дайджестов\n" +
+			"/settings exclude topic — исключить текущую тему из дайджестов",
+		"settings.admin_only":        "Изменять настройки могут только администраторы чата.",
+		"settings.back":              "« Назад",
+		"settings.close":             "Закрыть",
+		"settings.top":               "Топ: %d",
+		"settings.period":            "Период: %s",
+		"settings.sort":              "Сортировка",
+		"settings.language":          "Язык: %s",
+		"settings.timezone":          "Часовой пояс: %s",
+		"settings.excluded":          "Исключения (%d)",
+		"settings.excluded_user":     "✖ Пользователь %d",
+		"settings.exclude_bots":      "Скрывать ботов",
+		"settings.exclude_anonymous": "Скрывать анонимных админов",
+		"settings.excluded_topic":    "✖ Тема «%s»",
+		"settings.reply_required":    "Ответьте этой командой на сообщение пользователя.",
+		"settings.topic_required":    "Команду нужно отправить в теме форума.",
+		"settings.user_included":     "Пользователь %s снова участвует в дайджестах.",
+		"settings.user_excluded":     "Пользователь %s исключён из дайджестов.",
+		"settings.topic_included":    "Тема снова участвует в дайджестах.",
+		"settings.topic_excluded":    "Тема исключена из дайджестов.",
+		"settings.weight_saved":      "Вес реакции %s: %s.",
+		"settings.weight_invalid":    "Вес должен быть числом от -%[1]d до %[1]d.",
+		"settings.err.timezone":      "неизвестный часовой пояс «%s»",
+	},
+	langEn: {
+		"period.hour":         "the past hour",
+		"period.day":          "the past day",
+		"period.week":         "the past week",
+		"period.month":        "the past month",
+		"period.all":          "all time",
+		"period.hour.button":  "Past hour",
+		"period.day.button":   "Past day",
+		"period.week.button":  "Past week",
+		"period.month.button": "Past month",
+		"period.all.button":   "All time",
+		"period.today":        "today",
+		"period.yesterday":    "yesterday",
+		"period.this_week":    "this week",
+		"period.last_week":    "last week",
+		"period.this_month":   "this month",
+		"period.last_month":   "last month",
+		"period.date":         "2006-01-02",
+		"period.range":        "%s – %s",
+		"period.help": "Periods:\n" +
+			"%[1]s 12h, %[1]s 3d, %[1]s 2w — the past hours, days or weeks\n" +
+			"%[1]s today, %[1]s yesterday — today or yesterday\n" +
+			"%[1]s this-week, %[1]s last-week — this or last week\n" +
+			"%[1]s this-month, %[1]s last-month — this or last month\n" +
+			"%[1]s 2025-09-01..2025-09-30 — dates\n\n" +
+			"Calendar periods are taken in chat timezone, weeks start on Monday.",
+		"period.err.empty":    "period is not set",
+		"period.err.too_long": "period %q must be positive and not longer than %d days",
+		"period.err.unknown":  "unknown period %q",
+		"period.err.date":     "invalid date %q",
+		"period.err.reversed": "period %q ends before it starts",
+		"period.err.future":   "period %q starts in the future",
+
+		"sort.reactions":        "by reactions",
+		"sort.reactions.button": "By reactions",
+		"sort.reactors":         "by participants",
+		"sort.reactors.button":  "By participants",
+		"sort.score":            "by score",
+		"sort.score.button":     "By score",
+		"sort.hot":              "trending",
+		"sort.hot.button":       "🔥 Trending",
+
+		"digest.choose":        "Choose digest period:",
+		"digest.dm":            "📬 Get in private messages",
+		"digest.all_topics":    "All topics",
+		"digest.current_topic": "Current topic",
+		"digest.general_topic": "General",
+		"digest.header":        "Top messages %s in the chat for %s:",
+		"digest.header_topic":  "Top messages %s in topic «%s» for %s:",
+		"digest.period_stats":  "%s in period (%d total), %s",
+		"digest.score":         "score %s",
+
+		"message.default":     "Message",
+		"media.photo":         "photo",
+		"media.video":         "video",
+		"media.animation":     "GIF",
+		"media.audio":         "audio",
+		"media.voice":         "voice",
+		"media.video_note":    "video message",
+		"media.document":      "file",
+		"media.sticker":       "sticker",
+		"media.poll":          "poll",
+		"media.location":      "location",
+		"media.contact":       "contact",
+		"media.dice":          "dice",
+		"media.story":         "story",
+		"error":               "Error: %s",
+		"no_reactions":        "No reactions for this period.",
+		"schedule.admin_only": "Only chat admins can change schedules.",
+		"language.auto":       "auto",
+		"schedule.current":    "Current schedules:",
+		"schedule.delete":     "Specify schedule number: /schedule delete <id>",
+		"schedule.not_found":  "Schedule not found.",
+		"schedule.deleted":    "Schedule deleted.",
+		"schedule.added":      "Schedule added: %s",
+		"schedule.item":       "#%d «%s» (%s) for %s %s",
+		"schedule.quiet":      ", quiet hours %s-%s",
+		"schedule.next":       ", next %s",
+		"schedule.help": "Digest schedules:\n" +
+			"/schedule daily 21:00 — every day\n" +
+			"/schedule weekly mon 10:00 — once a week\n" +
+			"/schedule cron 0 21 * * 1-5 — cron expression\n" +
+			"/schedule delete <id> — delete schedule\n\n" +
+			"Options: tz=Europe/London period=day sort=reactions quiet=23:00-08:00\n" +
+			"Digest is posted to the topic where schedule was created.",
+		"schedule.err.empty":            "schedule is not set",
+		"schedule.err.unknown":          "unknown schedule %q",
+		"schedule.err.time_required":    "time is required",
+		"schedule.err.weekday_required": "weekday and time are required",
+		"schedule.err.weekday":          "invalid weekday %q",
+		"schedule.err.time":             "invalid time %q",
+		"schedule.err.cron_fields":      "cron expression must have 5 fields",
+		"schedule.err.cron":             "invalid cron expression %q",
+		"schedule.err.never":            "cron expression %q never matches",
+		"schedule.err.option":           "invalid option %q",
+		"schedule.err.unknown_option":   "unknown option %q",
+		"schedule.err.quiet":            "invalid quiet hours %q",
+		"schedule.err.timezone":         "invalid timezone %q",
+		"schedule.err.sort":             "invalid sort %q",
+
+		"authors.header": "Top authors for %s:",
+		"authors.page":   "page %d of %d",
+		"authors.prev":   "« Back",
+		"authors.next":   "Next »",
+
+		"reactors.header":    "Most generous for %s:",
+		"reactors.favorite":  "favourite %s",
+		"reactors.hint":      "Hide yourself from the leaderboard: %[1]s optout, return: %[1]s optin",
+		"reactors.opted_out": "You are hidden from the generous leaderboard.",
+		"reactors.opted_in":  "You are back in the generous leaderboard.",
+
+		"stats.header":      "Stats for %s:",
+		"stats.reactions":   "Reactions: %d",
+		"stats.trend":       "%s %d%% vs previous period",
+		"stats.trend_new":   "no reactions in previous period",
+		"stats.messages":    "Messages with reactions: %d",
+		"stats.per_message": "Per message: average %.1f, median %.1f",
+		"stats.top":         "Most used: %s",
+		"stats.busiest_day": "Busiest day: %s, %s",
+
+		"chart.header":  "Reactions for %s",
+		"chart.summary": "Total: %d, peak: %d (%s)",
+
+		"heatmap.header":   "Activity by hour for %s (%s):",
+		"heatmap.weekdays": "Mo Tu We Th Fr Sa Su",
+		"heatmap.peak":     "Peak: %s %02d:00, %s",
+		"heatmap.scale":    "%s — no reactions, %s — peak",
+
+		"start.help": "I collect digests of messages with most reactions. Add me to a group and use /digest, " +
+			"/topauthors, /topreactors, /stats, /chart and /heatmap.",
+		"dm.choose_chat":   "Choose chat to get digest of:",
+		"dm.choose_period": "Digest of «%s» for:",
+		"dm.no_chats":      "No chats shared with you found. Write or react in a chat with the bot and try again.",
+		"dm.not_member":    "Digest is available to chat members only.",
+
+		"inline.description": "Digest for %s",
+
+		"settings.title": "Digest settings:\nTop size: %d\nDefault period: %s\nSort: %s\nLanguage: %s\n" +
+			"Timezone: %s\nExcluded: %s, %s",
+		"settings.help": "Settings commands:\n" +
+			"/settings tz Europe/London — timezone\n" +
+			"/settings weight 👎 -1 — reaction weight in sorting by score, 1 by default\n" +
+			"/settings exclude user — reply to a message to exclude its author from digests\n" +
+			"/settings exclude topic — exclude current topic from digests",
+		"settings.admin_only":        "Only chat admins can change settings.",
+		"settings.back":              "« Back",
+		"settings.close":             "Close",
+		"settings.top":               "Top: %d",
+		"settings.period":            "Period: %s",
+		"settings.sort":              "Sort",
+		"settings.language":          "Language: %s",
+		"settings.timezone":          "Timezone: %s",
+		"settings.excluded":          "Exclusions (%d)",
+		"settings.excluded_user":     "✖ User %d",
+		"settings.exclude_bots":      "Hide bots",
+		"settings.exclude_anonymous": "Hide anonymous admins",
+		"settings.excluded_topic":    "✖ Topic «%s»",
+		"settings.reply_required":    "Reply with this command to a user's message.",
+		"settings.topic_required":    "Send this command in a forum topic.",
+		"settings.user_included":     "User %s is included in digests again.",
+		"settings.user_excluded":     "User %s is excluded from digests.",
+		"settings.topic_included":    "Topic is included in digests again.",
+		"settings.topic_excluded":    "Topic is excluded from digests.",
+		"settings.weight_saved":      "Weight of %s reaction: %s.",
+		"settings.weight_invalid":    "Weight must be a number from -%[1]d to %[1]d.",
+		"settings.err.timezone":      "unknown timezone %q",
+	},
+}
+
+// pluralCatalog is count formats by language and key in plural forms: one, few, many.
+// Languages with two forms repeat the second one.
+var pluralCatalog = map[string]map[string][3]string{
+	langRu: {
+		"period.hours": {"%d час", "%d часа", "%d часов"},
+		"period.days":  {"%d день", "%d дня", "%d дней"},
+		"period.weeks": {"%d неделю", "%d недели", "%d недель"},
+		"reactions":    {"%d реакция", "%d реакции", "%d реакций"},
+		"reactors":     {"%d участник", "%d участника", "%d участников"},
+		"messages":     {"%d сообщение", "%d сообщения", "%d сообщений"},
+		"users":        {"%d пользователь", "%d пользователя", "%d пользователей"},
+		"topics":       {"%d тема", "%d темы", "%d тем"},
+		"digest.more":  {"…и ещё %d сообщение", "…и ещё %d сообщения", "…и ещё %d сообщений"},
+	},
+	langEn: {
+		"period.hours": {"the past %d hour", "the past %d hours", "the past %d hours"},
+		"period.days":  {"the past %d day", "the past %d days", "the past %d days"},
+		"period.weeks": {"the past %d week", "the past %d weeks", "the past %d weeks"},
+		"reactions":    {"%d reaction", "%d reactions", "%d reactions"},
+		"reactors":     {"%d participant", "%d participants", "%d participants"},
+		"messages":     {"%d message", "%d messages", "%d messages"},
+		"users":        {"%d user", "%d users", "%d users"},
+		"topics":       {"%d topic", "%d topics", "%d topics"},
+		"digest.more":  {"…and %d more message", "…and %d more messages", "…and %d more messages"},
+	},
+}
+
+// pluralForm returns index of plural form of n in language: one, few or many.
+func pluralForm(lang string, n int) int {
+	if n < 0 {
+		n = -n
+	}
+
+	if lang == langRu {
+		switch {
+		case n%10 == 1 && n%100 != 11:
+			return 0
+		case n%10 >= 2 && n%10 <= 4 && (n%100 < 12 || n%100 > 14):
+			return 1
+		}
+		return 2
+	}
+
+	if n == 1 {
+		return 0
+	}
+
+	return 2
+}
+
+// localizer formats bot texts in one language.
+type localizer struct {
+	lang string
+}
+
+// newLocalizer returns localizer of lang, unknown languages fall back to default language.
+func newLocalizer(lang string) localizer {
+	if _, ok := catalog[lang]; !ok {
+		lang = defaultLanguage
+	}
+
+	return localizer{lang: lang}
+}
+
+// T returns text by key formatted with args. Keys missing in language are taken from default language.
+func (l localizer) T(key string, args ...interface{}) string {
+	format, ok := catalog[l.lang][key]
+	if !ok {
+		if format, ok = catalog[defaultLanguage][key]; !ok {
+			return key
+		}
+	}
+
+	if len(args) == 0 {
+		return format
+	}
+
+	return fmt.Sprintf(format, args...)
+}
+
+// E returns error text, input errors are translated.
+func (l localizer) E(err error) string {
+	var ie inputError
+	if errors.As(err, &ie) {
+		return l.T("error", l.T(ie.Key, ie.Args...))
+	}
+
+	return l.T("error", err)
+}
+
+// N returns count text by key in plural form matching n, like "5 реакций".
+func (l localizer) N(key string, n int) string {
+	forms, ok := pluralCatalog[l.lang][key]
+	if !ok {
+		if forms, ok = pluralCatalog[defaultLanguage][key]; !ok {
+			return fmt.Sprintf("%d %s", n, key)
+		}
+	}
+
+	return fmt.Sprintf(forms[pluralForm(l.lang, n)], n)
+}
+
+// inputError is error of user input like invalid command arguments, its text is translated to chat language.
+type inputError struct {
+	// Key is catalog key of error text formatted with Args.
+	Key  string
+	Args []interface{}
+}
+
+func newInputError(key string, args ...interface{}) error {
+	return inputError{Key: key, Args: args}
+}
+
+// Error returns error text in fallback language for logs.
+func (e inputError) Error() string {
+	return newLocalizer(fallbackLanguage).T(e.Key, e.Args...)
+}
+
+// chatLanguage returns language from chat settings, falling back to requester language_code. Requesters with
+// language missing in catalog get fallback language, digests without requester get default language.
+func chatLanguage(cs db.ChatSetting, requester *models.User) string {
+	if lang := settingLanguage(cs); lang != "" {
+		return lang
+	}
+	if requester == nil || requester.LanguageCode == "" {
+		return defaultLanguage
+	}
+
+	lang := strings.ToLower(strings.SplitN(requester.LanguageCode, "-", 2)[0])
+	if _, ok := catalog[lang]; ok {
+		return lang
+	}
+
+	return fallbackLanguage
+}
+
+// chatLocalizer returns localizer for chat settings and requester.
+func chatLocalizer(cs db.ChatSetting, requester *models.User) localizer {
+	return newLocalizer(chatLanguage(cs, requester))
+}
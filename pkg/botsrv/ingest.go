@@ -0,0 +1,112 @@
+package botsrv
+
+import (
+	"context"
+	"time"
+
+	"botsrv/pkg/db"
+
+	"github.com/go-telegram/bot/models"
+)
+
+// saveMessage stores metadata of messages from tracked chats for digests.
+func (bm *BotManager) saveMessage(ctx context.Context, m *models.Message) error {
+	if !isTrackedChat(m.Chat) {
+		return nil
+	}
+
+	switch {
+	case m.MigrateToChatID != 0:
+		return bm.migrateChat(ctx, m.Chat.ID, m.MigrateToChatID)
+	case m.MigrateFromChatID != 0:
+		return bm.migrateChat(ctx, m.MigrateFromChatID, m.Chat.ID)
+	}
+
+	if err := bm.registerChat(ctx, m.Chat); err != nil {
+		return err
+	}
+	if err := bm.cr.SaveMessage(ctx, newMessage(m)); err != nil {
+		return err
+	}
+
+	if m.NewChatTitle != "" {
+		if err := bm.saveChatInfo(ctx, m.Chat); err != nil {
+			return err
+		}
+	}
+
+	return bm.saveForumTopic(ctx, m)
+}
+
+// saveForumTopic stores forum topic title from forum_topic_created and forum_topic_edited service messages.
+func (bm *BotManager) saveForumTopic(ctx context.Context, m *models.Message) error {
+	var title string
+	switch {
+	case m.ForumTopicCreated != nil:
+		title = m.ForumTopicCreated.Name
+	case m.ForumTopicEdited != nil:
+		title = m.ForumTopicEdited.Name
+	}
+
+	if title == "" || m.MessageThreadID == 0 {
+		return nil
+	}
+
+	return bm.cr.SaveForumTopic(ctx, &db.ForumTopic{
+		ChatID:    m.Chat.ID,
+		ThreadID:  m.MessageThreadID,
+		Title:     title,
+		UpdatedAt: time.Now(),
+	})
+}
+
+// saveMessageReaction buffers user reaction change to message counters and reaction events log.
+func (bm *BotManager) saveMessageReaction(ctx context.Context, mru *models.MessageReactionUpdated) error {
+	deltas := reactionDeltas(mru.OldReaction, mru.NewReaction)
+	reactors := reactorDelta(mru.OldReaction, mru.NewReaction)
+	if len(deltas) == 0 && reactors == 0 {
+		return nil
+	}
+
+	if err := bm.registerChat(ctx, mru.Chat); err != nil {
+		return err
+	}
+
+	var user *db.User
+	if mru.User != nil {
+		user = newUser(mru.User)
+	}
+
+	mk := messageKey{ChatID: mru.Chat.ID, MessageID: mru.MessageID}
+	bm.ra.AddReaction(mk, deltas, reactors, time.Unix(int64(mru.Date), 0), newReactionEvents(mru, deltas), user)
+
+	return nil
+}
+
+// saveMessageReactionCount buffers anonymous reaction totals. Telegram sends them for channels and anonymous
+// reactions with a delay, so they are authoritative: they replace per-reaction counters accumulated from
+// user updates. Updates older than the last applied one are skipped.
+func (bm *BotManager) saveMessageReactionCount(ctx context.Context, mrcu *models.MessageReactionCountUpdated) error {
+	total := 0
+	counts := make([]db.MessageReactionCount, 0, len(mrcu.Reactions))
+	for _, rc := range mrcu.Reactions {
+		key, ok := newReactionKey(rc.Type)
+		if !ok {
+			continue
+		}
+		counts = append(counts, db.MessageReactionCount{
+			ChatID:       mrcu.Chat.ID,
+			MessageID:    mrcu.MessageID,
+			ReactionType: key.Type,
+			Reaction:     key.Reaction,
+			Count:        rc.TotalCount,
+			UpdatedAt:    time.Now(),
+		})
+		total += rc.TotalCount
+	}
+
+	mk := messageKey{ChatID: mrcu.Chat.ID, MessageID: mrcu.MessageID}
+	bm.ra.SyncCounts(mk, total, time.Unix(int64(mrcu.Date), 0), counts)
+
+	return nil
+}
@@ -0,0 +1,165 @@
+package botsrv
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+const (
+	// inlineCacheTTL is how long inline results are reused, the same time telegram caches them on its side.
+	inlineCacheTTL = time.Minute
+	// maxInlineResults limits digests built for inline query, each one takes several DB queries and chats are
+	// checked for membership only until this many are found.
+	maxInlineResults = 5
+)
+
+// ttlCacheEntry is cached value with its expiration time.
+type ttlCacheEntry[V any] struct {
+	value     V
+	expiresAt time.Time
+}
+
+// ttlCache keeps values for ttl. It caches inline query results by user and query, so typing doesn't rebuild
+// digests on each key press, and chat membership of users, so it isn't checked in telegram for each query.
+type ttlCache[V any] struct {
+	ttl     time.Duration
+	mu      sync.Mutex
+	entries map[string]ttlCacheEntry[V]
+}
+
+func newTTLCache[V any](ttl time.Duration) *ttlCache[V] {
+	return &ttlCache[V]{ttl: ttl, entries: make(map[string]ttlCacheEntry[V])}
+}
+
+// inlineCacheKey returns cache key of the user query.
+func inlineCacheKey(userID int64, query string) string {
+	return strconv.FormatInt(userID, 10) + ":" + query
+}
+
+// Get returns value if it is not expired.
+func (c *ttlCache[V]) Get(key string, now time.Time) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[key]
+	if !ok || !now.Before(e.expiresAt) {
+		var zero V
+		return zero, false
+	}
+
+	return e.value, true
+}
+
+// Set stores value and drops expired entries.
+func (c *ttlCache[V]) Set(key string, value V, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for k, e := range c.entries {
+		if !now.Before(e.expiresAt) {
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = ttlCacheEntry[V]{value: value, expiresAt: now.Add(c.ttl)}
+}
+
+// isInlineQuery matches inline query updates.
+func isInlineQuery(update *models.Update) bool {
+	return update.InlineQuery != nil
+}
+
+// inlinePeriod returns digest period of inline query, bare "week" means the current calendar week.
+func inlinePeriod(spec string) string {
+	if spec == "week" {
+		return periodThisWeek
+	}
+
+	return spec
+}
+
+// inlineResults builds digest article for each chat shared by the user with bot. Empty spec means chat default
+// period, digests of forums cover all topics. Period is taken in chat timezone, chats where it is invalid are skipped.
+func (bm *BotManager) inlineResults(ctx context.Context, b *bot.Bot, u *models.User, spec string) ([]models.InlineQueryResult, error) {
+	chats, err := bm.memberChats(ctx, b, u.ID, maxInlineResults)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	results := make([]models.InlineQueryResult, 0, len(chats))
+	for i := range chats {
+		cs, err := bm.chatSetting(ctx, chats[i].ID)
+		if err != nil {
+			return nil, err
+		}
+		l := chatLocalizer(cs, u)
+
+		req := digestRequest{Period: inlinePeriod(spec), Sort: cs.Sort, AllTopics: true}
+		if req.Period == "" {
+			req.Period = cs.Period
+		}
+		if _, err = parsePeriod(req.Period, now, settingLocation(cs)); err != nil {
+			continue
+		}
+
+		chat := chatModel(&chats[i])
+		digest, err := bm.digestText(ctx, l, cs, chat, 0, req)
+		if err != nil {
+			return nil, err
+		}
+
+		title := "💬 " + escapeHTML(chatTitle(chat)) + "\n"
+		results = append(results, &models.InlineQueryResultArticle{
+			ID:          strconv.FormatInt(chat.ID, 10),
+			Title:       chatTitle(chat),
+			Description: l.T("inline.description", periodTitle(l, req.Period)),
+			InputMessageContent: &models.InputTextMessageContent{
+				MessageText:        title + digest.Truncate(l, maxMessageLength-textLength(title)),
+				ParseMode:          models.ParseModeHTML,
+				LinkPreviewOptions: disabledLinkPreview,
+			},
+		})
+	}
+
+	return results, nil
+}
+
+// InlineQueryHandler answers "@bot week" with digests of chats the user is member of for period from query, bare
+// "week" is the current week. Results are personal and cached for inlineCacheTTL, invalid period gets no results.
+func (bm *BotManager) InlineQueryHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	iq := update.InlineQuery
+	if iq == nil || iq.From == nil {
+		return
+	}
+
+	now := time.Now()
+	spec := strings.ToLower(strings.Join(strings.Fields(iq.Query), ""))
+	key := inlineCacheKey(iq.From.ID, spec)
+
+	results, ok := bm.inline.Get(key, now)
+	if !ok {
+		var err error
+		if results, err = bm.inlineResults(ctx, b, iq.From, spec); err != nil {
+			bm.Errorf("Failed to build inline digests: %v", err)
+			return
+		}
+		bm.inline.Set(key, results, now)
+	}
+
+	_, err := b.AnswerInlineQuery(ctx, &bot.AnswerInlineQueryParams{
+		InlineQueryID: iq.ID,
+		Results:       results,
+		CacheTime:     int(inlineCacheTTL / time.Second),
+		IsPersonal:    true,
+	})
+	if err != nil {
+		bm.Errorf("%v", err)
+		return
+	}
+}
@@ -0,0 +1,200 @@
+package botsrv
+
+import (
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"botsrv/pkg/db"
+
+	"github.com/go-telegram/bot/models"
+)
+
+const (
+	// messageTextLength is the max length of stored message text snippet.
+	messageTextLength = 255
+	// messagePreviewLength is the max length of message snippet in digest.
+	messagePreviewLength = 60
+
+	mediaKindPhoto     = "photo"
+	mediaKindVideo     = "video"
+	mediaKindAnimation = "animation"
+	mediaKindAudio     = "audio"
+	mediaKindVoice     = "voice"
+	mediaKindVideoNote = "video_note"
+	mediaKindDocument  = "document"
+	mediaKindSticker   = "sticker"
+	mediaKindPoll      = "poll"
+	mediaKindLocation  = "location"
+	mediaKindContact   = "contact"
+	mediaKindDice      = "dice"
+	mediaKindStory     = "story"
+)
+
+// isTrackedChat returns true for chats where bot collects reactions.
+func isTrackedChat(chat models.Chat) bool {
+	switch chat.Type {
+	case models.ChatTypeGroup, models.ChatTypeSupergroup, models.ChatTypeChannel:
+		return true
+	}
+
+	return false
+}
+
+// newMessage converts telegram message into stored message metadata.
+func newMessage(m *models.Message) *db.Message {
+	msg := &db.Message{
+		ChatID:    m.Chat.ID,
+		MessageID: m.ID,
+		SentAt:    time.Unix(int64(m.Date), 0),
+	}
+
+	if tid := messageThread(m); tid != 0 {
+		msg.ThreadID = &tid
+	}
+	if m.From != nil {
+		msg.UserID = &m.From.ID
+		// anonymous admins are sent by service bot on behalf of sender chat
+		msg.IsBot = m.From.IsBot && m.SenderChat == nil
+	}
+	if m.SenderChat != nil {
+		msg.SenderChatID = &m.SenderChat.ID
+	}
+	if name := messageAuthorName(m); name != "" {
+		msg.AuthorName = &name
+	}
+	if kind := messageMediaKind(m); kind != "" {
+		msg.MediaKind = &kind
+	}
+
+	text := m.Text
+	if text == "" {
+		text = m.Caption
+	}
+	if text = truncate(text, messageTextLength); text != "" {
+		msg.Text = &text
+	}
+
+	return msg
+}
+
+// messageThread returns forum topic of the message in forums and comments thread in other supergroups,
+// 0 for messages out of thread. Replies in forum General topic have thread of replied message, which is not a topic.
+func messageThread(m *models.Message) int {
+	if m.Chat.IsForum && !m.IsTopicMessage {
+		return 0
+	}
+
+	return m.MessageThreadID
+}
+
+// messageAuthorName returns display name of message author: sender chat for anonymous admins and channels, user otherwise.
+func messageAuthorName(m *models.Message) string {
+	if m.SenderChat != nil {
+		name := m.SenderChat.Title
+		if m.AuthorSignature != "" {
+			name += " (" + m.AuthorSignature + ")"
+		}
+		return name
+	}
+
+	if m.From != nil {
+		return userName(m.From)
+	}
+
+	return ""
+}
+
+// userName returns user full name or username.
+func userName(u *models.User) string {
+	name := strings.TrimSpace(u.FirstName + " " + u.LastName)
+	if name == "" && u.Username != "" {
+		name = "@" + u.Username
+	}
+
+	return name
+}
+
+// newUser returns user with the current name.
+func newUser(u *models.User) *db.User {
+	user := &db.User{ID: u.ID, Name: userName(u), UpdatedAt: time.Now()}
+	if u.Username != "" {
+		user.Username = &u.Username
+	}
+
+	return user
+}
+
+// messageMediaKind returns kind of message media or empty string for text messages.
+func messageMediaKind(m *models.Message) string {
+	switch {
+	case len(m.Photo) > 0:
+		return mediaKindPhoto
+	case m.Video != nil:
+		return mediaKindVideo
+	case m.Animation != nil:
+		return mediaKindAnimation
+	case m.Audio != nil:
+		return mediaKindAudio
+	case m.Voice != nil:
+		return mediaKindVoice
+	case m.VideoNote != nil:
+		return mediaKindVideoNote
+	case m.Document != nil:
+		return mediaKindDocument
+	case m.Sticker != nil:
+		return mediaKindSticker
+	case m.Poll != nil:
+		return mediaKindPoll
+	case m.Location != nil, m.Venue != nil:
+		return mediaKindLocation
+	case m.Contact != nil:
+		return mediaKindContact
+	case m.Dice != nil:
+		return mediaKindDice
+	case m.Story != nil:
+		return mediaKindStory
+	}
+
+	return ""
+}
+
+// messagePreview returns short message description for digest like `Author: «text»`.
+func messagePreview(l localizer, m *db.Message) string {
+	if m == nil {
+		return l.T("message.default")
+	}
+
+	var preview string
+	if m.Text != nil {
+		preview = "«" + truncate(*m.Text, messagePreviewLength) + "»"
+	}
+	if m.MediaKind != nil {
+		kind := "[" + l.T("media."+*m.MediaKind) + "]"
+		if preview == "" {
+			preview = kind
+		} else {
+			preview = kind + " " + preview
+		}
+	}
+	if preview == "" {
+		preview = l.T("message.default")
+	}
+
+	if m.AuthorName != nil {
+		preview = *m.AuthorName + ": " + preview
+	}
+
+	return preview
+}
+
+// truncate collapses whitespaces and cuts text to limit runes adding ellipsis.
+func truncate(text string, limit int) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if utf8.RuneCountInString(text) <= limit {
+		return text
+	}
+
+	runes := []rune(text)
+	return strings.TrimSpace(string(runes[:limit-1])) + "…"
+}
@@ -0,0 +1,148 @@
+package botsrv
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	periodAll       = "all"
+	periodToday     = "today"
+	periodYesterday = "yesterday"
+	periodThisWeek  = "this-week"
+	periodLastWeek  = "last-week"
+	periodThisMonth = "this-month"
+	periodLastMonth = "last-month"
+
+	// periodDateLayout is the date layout of date range periods like "2025-09-01..2025-09-30".
+	periodDateLayout = "2006-01-02"
+	periodRangeSep   = ".."
+
+	// maxRollingPeriod limits rolling periods like "12h" and "3d".
+	maxRollingPeriod = 366 * 24 * time.Hour
+)
+
+// rollingUnits is units of rolling periods with plural catalog keys of their titles.
+var rollingUnits = map[byte]struct {
+	Unit  time.Duration
+	Title string
+}{
+	'h': {Unit: time.Hour, Title: "period.hours"},
+	'd': {Unit: 24 * time.Hour, Title: "period.days"},
+	'w': {Unit: 7 * 24 * time.Hour, Title: "period.weeks"},
+}
+
+// digestPeriod is digest time range [From, To). From is zero for all time digest.
+type digestPeriod struct {
+	From time.Time
+	To   time.Time
+	// Title is catalog key of period title, plural key if N is set. Date ranges are titled by dates.
+	Title string
+	N     int
+}
+
+// Windowed returns true if digest is limited by period.
+func (p digestPeriod) Windowed() bool {
+	return !p.From.IsZero()
+}
+
+// title returns localized period title.
+func (p digestPeriod) title(l localizer) string {
+	switch {
+	case p.Title == "":
+		first, last := p.From.Format(l.T("period.date")), p.To.AddDate(0, 0, -1).Format(l.T("period.date"))
+		if first == last {
+			return first
+		}
+		return l.T("period.range", first, last)
+	case p.N > 0:
+		return l.N(p.Title, p.N)
+	}
+
+	return l.T(p.Title)
+}
+
+// parsePeriod parses digest period: fixed periods like "day", rolling periods like "12h", "3d", "2w", calendar
+// periods like "yesterday", "last-week" and date ranges like "2025-09-01..2025-09-30" or single dates. Calendar
+// periods and dates are taken in loc, weeks start on Monday.
+func parsePeriod(spec string, now time.Time, loc *time.Location) (digestPeriod, error) {
+	spec = strings.ToLower(strings.TrimSpace(spec))
+	now = now.In(loc)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	// days since Monday
+	weekday := (int(today.Weekday()) + 6) % 7
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, loc)
+
+	if rp, ok := reactionPeriods[paternDigest+spec]; ok {
+		p := digestPeriod{To: now, Title: rp.Title}
+		if rp.Period > 0 {
+			p.From = now.Add(-rp.Period)
+		}
+		return p, nil
+	}
+
+	switch spec {
+	case periodToday:
+		return digestPeriod{From: today, To: now, Title: "period.today"}, nil
+	case periodYesterday:
+		return digestPeriod{From: today.AddDate(0, 0, -1), To: today, Title: "period.yesterday"}, nil
+	case periodThisWeek:
+		return digestPeriod{From: today.AddDate(0, 0, -weekday), To: now, Title: "period.this_week"}, nil
+	case periodLastWeek:
+		return digestPeriod{From: today.AddDate(0, 0, -weekday-7), To: today.AddDate(0, 0, -weekday), Title: "period.last_week"}, nil
+	case periodThisMonth:
+		return digestPeriod{From: month, To: now, Title: "period.this_month"}, nil
+	case periodLastMonth:
+		return digestPeriod{From: month.AddDate(0, -1, 0), To: month, Title: "period.last_month"}, nil
+	case "":
+		return digestPeriod{}, newInputError("period.err.empty")
+	}
+
+	if unit, ok := rollingUnits[spec[len(spec)-1]]; ok {
+		if n, err := strconv.Atoi(spec[:len(spec)-1]); err == nil {
+			if n <= 0 || time.Duration(n) > maxRollingPeriod/unit.Unit {
+				return digestPeriod{}, newInputError("period.err.too_long", spec, int(maxRollingPeriod/(24*time.Hour)))
+			}
+			return digestPeriod{From: now.Add(-time.Duration(n) * unit.Unit), To: now, Title: unit.Title, N: n}, nil
+		}
+	}
+
+	return parseDateRange(spec, now, loc)
+}
+
+// parseDateRange parses inclusive date range like "2025-09-01..2025-09-30" or single date in loc.
+func parseDateRange(spec string, now time.Time, loc *time.Location) (digestPeriod, error) {
+	first, last, isRange := strings.Cut(spec, periodRangeSep)
+	if !isRange {
+		last = first
+	}
+
+	from, err := time.ParseInLocation(periodDateLayout, first, loc)
+	if err != nil {
+		return digestPeriod{}, newInputError("period.err.unknown", spec)
+	}
+	to, err := time.ParseInLocation(periodDateLayout, last, loc)
+	if err != nil {
+		return digestPeriod{}, newInputError("period.err.date", last)
+	}
+
+	switch {
+	case to.Before(from):
+		return digestPeriod{}, newInputError("period.err.reversed", spec)
+	case from.After(now):
+		return digestPeriod{}, newInputError("period.err.future", spec)
+	}
+
+	return digestPeriod{From: from, To: to.AddDate(0, 0, 1)}, nil
+}
+
+// periodTitle returns localized title of period spec, spec itself for invalid period.
+func periodTitle(l localizer, spec string) string {
+	p, err := parsePeriod(spec, time.Now(), time.UTC)
+	if err != nil {
+		return spec
+	}
+
+	return p.title(l)
+}
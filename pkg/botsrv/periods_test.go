@@ -0,0 +1,97 @@
+package botsrv
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParsePeriod(t *testing.T) {
+	kolkata, err := time.LoadLocation("Asia/Kolkata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	date := func(loc *time.Location, year int, month time.Month, day, hour, min int) time.Time {
+		return time.Date(year, month, day, hour, min, 0, 0, loc)
+	}
+
+	// Monday, 2025-09-01 10:30 in Kolkata
+	monday := date(kolkata, 2025, 9, 1, 10, 30)
+	sunday := date(kolkata, 2025, 8, 31, 23, 0)
+
+	tests := []struct {
+		name     string
+		spec     string
+		now      time.Time
+		loc      *time.Location
+		wantFrom time.Time
+		wantTo   time.Time
+		wantN    int
+		wantErr  string
+	}{
+		{name: "fixed week", spec: "week", now: monday, wantFrom: monday.AddDate(0, 0, -7), wantTo: monday},
+		{name: "all time", spec: "all", now: monday, wantTo: monday},
+		{name: "today", spec: " Today ", now: monday, wantFrom: date(kolkata, 2025, 9, 1, 0, 0), wantTo: monday},
+		{name: "yesterday", spec: "yesterday", now: monday, wantFrom: date(kolkata, 2025, 8, 31, 0, 0), wantTo: date(kolkata, 2025, 9, 1, 0, 0)},
+		{name: "this week on monday", spec: "this-week", now: monday, wantFrom: date(kolkata, 2025, 9, 1, 0, 0), wantTo: monday},
+		{name: "this week on sunday", spec: "this-week", now: sunday, wantFrom: date(kolkata, 2025, 8, 25, 0, 0), wantTo: sunday},
+		{name: "last week on monday", spec: "last-week", now: monday, wantFrom: date(kolkata, 2025, 8, 25, 0, 0), wantTo: date(kolkata, 2025, 9, 1, 0, 0)},
+		{name: "last week on sunday", spec: "last-week", now: sunday, wantFrom: date(kolkata, 2025, 8, 18, 0, 0), wantTo: date(kolkata, 2025, 8, 25, 0, 0)},
+		{name: "this month on first day", spec: "this-month", now: monday, wantFrom: date(kolkata, 2025, 9, 1, 0, 0), wantTo: monday},
+		{name: "this month on last day", spec: "this-month", now: sunday, wantFrom: date(kolkata, 2025, 8, 1, 0, 0), wantTo: sunday},
+		{name: "last month after february", spec: "last-month", now: date(kolkata, 2025, 3, 1, 0, 0),
+			wantFrom: date(kolkata, 2025, 2, 1, 0, 0), wantTo: date(kolkata, 2025, 3, 1, 0, 0)},
+		{name: "last month in january", spec: "last-month", now: date(kolkata, 2025, 1, 5, 12, 0),
+			wantFrom: date(kolkata, 2024, 12, 1, 0, 0), wantTo: date(kolkata, 2025, 1, 1, 0, 0)},
+		{name: "hours", spec: "12h", now: monday, wantFrom: monday.Add(-12 * time.Hour), wantTo: monday, wantN: 12},
+		{name: "days", spec: "3d", now: monday, wantFrom: monday.Add(-3 * 24 * time.Hour), wantTo: monday, wantN: 3},
+		{name: "weeks", spec: "2w", now: monday, wantFrom: monday.Add(-14 * 24 * time.Hour), wantTo: monday, wantN: 2},
+		{name: "max days", spec: "366d", now: monday, wantFrom: monday.Add(-maxRollingPeriod), wantTo: monday, wantN: 366},
+		{name: "too many days", spec: "367d", now: monday, wantErr: "period.err.too_long"},
+		{name: "too many weeks", spec: "53w", now: monday, wantErr: "period.err.too_long"},
+		{name: "zero hours", spec: "0h", now: monday, wantErr: "period.err.too_long"},
+		{name: "date range", spec: "2025-08-01..2025-08-31", now: monday,
+			wantFrom: date(kolkata, 2025, 8, 1, 0, 0), wantTo: date(kolkata, 2025, 9, 1, 0, 0)},
+		{name: "single date", spec: "2025-08-15", now: monday, wantFrom: date(kolkata, 2025, 8, 15, 0, 0), wantTo: date(kolkata, 2025, 8, 16, 0, 0)},
+		{name: "reversed range", spec: "2025-08-31..2025-08-01", now: monday, wantErr: "period.err.reversed"},
+		{name: "invalid last date", spec: "2025-08-01..2025-08-32", now: monday, wantErr: "period.err.date"},
+		{name: "invalid first date", spec: "2025-02-30..2025-03-01", now: monday, wantErr: "period.err.unknown"},
+		{name: "future range", spec: "2025-09-02..2025-09-03", now: monday, wantErr: "period.err.future"},
+		{name: "unknown", spec: "fortnight", now: monday, wantErr: "period.err.unknown"},
+		{name: "empty", spec: " ", now: monday, wantErr: "period.err.empty"},
+		// 2025-09-01 20:00 UTC is already 2025-09-02 01:30 in Kolkata
+		{name: "today in loc", spec: "today", now: date(time.UTC, 2025, 9, 1, 20, 0),
+			wantFrom: date(kolkata, 2025, 9, 2, 0, 0), wantTo: date(time.UTC, 2025, 9, 1, 20, 0)},
+		{name: "today in utc", spec: "today", now: date(time.UTC, 2025, 9, 1, 20, 0), loc: time.UTC,
+			wantFrom: date(time.UTC, 2025, 9, 1, 0, 0), wantTo: date(time.UTC, 2025, 9, 1, 20, 0)},
+		{name: "date in loc", spec: "2025-08-15", now: monday, loc: time.UTC,
+			wantFrom: date(time.UTC, 2025, 8, 15, 0, 0), wantTo: date(time.UTC, 2025, 8, 16, 0, 0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loc := tt.loc
+			if loc == nil {
+				loc = kolkata
+			}
+
+			got, err := parsePeriod(tt.spec, tt.now, loc)
+			if tt.wantErr != "" {
+				var ie inputError
+				if !errors.As(err, &ie) || ie.Key != tt.wantErr {
+					t.Fatalf("parsePeriod() err = %v, want %s", err, tt.wantErr)
+				}
+				return
+			} else if err != nil {
+				t.Fatal(err)
+			}
+
+			if !got.From.Equal(tt.wantFrom) || !got.To.Equal(tt.wantTo) {
+				t.Errorf("parsePeriod() = [%v, %v), want [%v, %v)", got.From, got.To, tt.wantFrom, tt.wantTo)
+			}
+			if got.N != tt.wantN {
+				t.Errorf("parsePeriod() N = %d, want %d", got.N, tt.wantN)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package botsrv
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/go-telegram/bot/models"
+)
+
+const (
+	permalinkHost = "https://t.me/"
+	// privateChatPrefix is the prefix of supergroup and channel IDs dropped in private links.
+	privateChatPrefix = "-100"
+)
+
+// messagePermalink returns link to the message or empty string if chat has no message links, like basic groups.
+// threadID is forum topic for forums and comments thread for other supergroups, 0 if message is not in thread.
+// Public chats are linked by username, private ones by internal ID, which is available to chat members only:
+//
+//	https://t.me/<username>/<msg>, https://t.me/c/<id>/<msg> – supergroups and channels,
+//	https://t.me/<username>/<thread>/<msg>, https://t.me/c/<id>/<thread>/<msg> – forum topics,
+//	https://t.me/<username>/<msg>?thread=<thread>, https://t.me/c/<id>/<msg>?thread=<thread> – discussion comments.
+func messagePermalink(chat models.Chat, messageID, threadID int) string {
+	if messageID <= 0 {
+		return ""
+	}
+
+	var link string
+	switch {
+	case chat.Type != models.ChatTypeSupergroup && chat.Type != models.ChatTypeChannel:
+		return ""
+	case chat.Username != "":
+		link = permalinkHost + chat.Username + "/"
+	default:
+		id := strconv.FormatInt(chat.ID, 10)
+		if !strings.HasPrefix(id, privateChatPrefix) {
+			return ""
+		}
+		link = permalinkHost + "c/" + strings.TrimPrefix(id, privateChatPrefix) + "/"
+	}
+
+	switch {
+	case threadID == 0 || chat.Type == models.ChatTypeChannel:
+		return link + strconv.Itoa(messageID)
+	case chat.IsForum:
+		return link + strconv.Itoa(threadID) + "/" + strconv.Itoa(messageID)
+	}
+
+	return link + strconv.Itoa(messageID) + "?thread=" + strconv.Itoa(threadID)
+}
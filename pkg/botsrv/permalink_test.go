@@ -0,0 +1,94 @@
+package botsrv
+
+import (
+	"testing"
+
+	"github.com/go-telegram/bot/models"
+)
+
+func TestMessagePermalink(t *testing.T) {
+	tests := []struct {
+		name      string
+		chat      models.Chat
+		messageID int
+		threadID  int
+		want      string
+	}{
+		{
+			name:      "basic group",
+			chat:      models.Chat{ID: -123456789, Type: models.ChatTypeGroup},
+			messageID: 42,
+		},
+		{
+			name:      "private supergroup",
+			chat:      models.Chat{ID: -1001234567890, Type: models.ChatTypeSupergroup},
+			messageID: 42,
+			want:      "https://t.me/c/1234567890/42",
+		},
+		{
+			name:      "public supergroup",
+			chat:      models.Chat{ID: -1001234567890, Type: models.ChatTypeSupergroup, Username: "golang_ru"},
+			messageID: 42,
+			want:      "https://t.me/golang_ru/42",
+		},
+		{
+			name:      "forum topic",
+			chat:      models.Chat{ID: -1001234567890, Type: models.ChatTypeSupergroup, IsForum: true},
+			messageID: 42,
+			threadID:  7,
+			want:      "https://t.me/c/1234567890/7/42",
+		},
+		{
+			name:      "public forum topic",
+			chat:      models.Chat{ID: -1001234567890, Type: models.ChatTypeSupergroup, IsForum: true, Username: "golang_ru"},
+			messageID: 42,
+			threadID:  7,
+			want:      "https://t.me/golang_ru/7/42",
+		},
+		{
+			name:      "forum general topic",
+			chat:      models.Chat{ID: -1001234567890, Type: models.ChatTypeSupergroup, IsForum: true},
+			messageID: 42,
+			want:      "https://t.me/c/1234567890/42",
+		},
+		{
+			name:      "channel ignores thread",
+			chat:      models.Chat{ID: -1001234567890, Type: models.ChatTypeChannel},
+			messageID: 42,
+			threadID:  7,
+			want:      "https://t.me/c/1234567890/42",
+		},
+		{
+			name:      "discussion comment",
+			chat:      models.Chat{ID: -1001234567890, Type: models.ChatTypeSupergroup},
+			messageID: 42,
+			threadID:  7,
+			want:      "https://t.me/c/1234567890/42?thread=7",
+		},
+		{
+			name:      "public discussion comment",
+			chat:      models.Chat{ID: -1001234567890, Type: models.ChatTypeSupergroup, Username: "golang_ru"},
+			messageID: 42,
+			threadID:  7,
+			want:      "https://t.me/golang_ru/42?thread=7",
+		},
+		{
+			name:      "supergroup without -100 prefix",
+			chat:      models.Chat{ID: -123456789, Type: models.ChatTypeSupergroup},
+			messageID: 42,
+		},
+		{
+			name:      "migrated basic group message",
+			chat:      models.Chat{ID: -1001234567890, Type: models.ChatTypeSupergroup},
+			messageID: -42,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := messagePermalink(tt.chat, tt.messageID, tt.threadID); got != tt.want {
+				t.Errorf("messagePermalink() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
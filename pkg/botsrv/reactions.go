@@ -0,0 +1,150 @@
+package botsrv
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"botsrv/pkg/db"
+
+	"github.com/go-telegram/bot/models"
+)
+
+const (
+	// paidReaction is stored as reaction value for paid (star) reactions.
+	paidReaction = "paid"
+
+	paidReactionLabel        = "⭐"
+	customEmojiReactionLabel = "✨"
+)
+
+// reactionKey identifies one reaction kind: emoji, custom emoji or paid reaction.
+type reactionKey struct {
+	Type     string
+	Reaction string
+}
+
+// newReactionKey converts telegram reaction into reactionKey. Returns false for unsupported reactions.
+func newReactionKey(rt models.ReactionType) (reactionKey, bool) {
+	switch rt.Type {
+	case models.ReactionTypeTypeEmoji:
+		if rt.ReactionTypeEmoji != nil {
+			return reactionKey{Type: string(rt.Type), Reaction: rt.ReactionTypeEmoji.Emoji}, true
+		}
+	case models.ReactionTypeTypeCustomEmoji:
+		if rt.ReactionTypeCustomEmoji != nil {
+			return reactionKey{Type: string(rt.Type), Reaction: rt.ReactionTypeCustomEmoji.CustomEmojiID}, true
+		}
+	case models.ReactionTypeTypePaid:
+		return reactionKey{Type: string(rt.Type), Reaction: paidReaction}, true
+	}
+
+	return reactionKey{}, false
+}
+
+// Label returns printable reaction representation. Custom emoji can't be shown in plain text, so they share one label.
+func (k reactionKey) Label() string {
+	switch models.ReactionTypeType(k.Type) {
+	case models.ReactionTypeTypeCustomEmoji:
+		return customEmojiReactionLabel
+	case models.ReactionTypeTypePaid:
+		return paidReactionLabel
+	}
+
+	return k.Reaction
+}
+
+// reactionDeltas returns non-zero count changes per reaction between old and new user reactions.
+func reactionDeltas(oldReactions, newReactions []models.ReactionType) map[reactionKey]int {
+	deltas := make(map[reactionKey]int, len(oldReactions)+len(newReactions))
+	for _, rt := range oldReactions {
+		if key, ok := newReactionKey(rt); ok {
+			deltas[key]--
+		}
+	}
+	for _, rt := range newReactions {
+		if key, ok := newReactionKey(rt); ok {
+			deltas[key]++
+		}
+	}
+
+	for key, delta := range deltas {
+		if delta == 0 {
+			delete(deltas, key)
+		}
+	}
+
+	return deltas
+}
+
+// reactorDelta returns unique reactors change for one user update: the user starts or stops reacting to the message.
+func reactorDelta(oldReactions, newReactions []models.ReactionType) int {
+	switch {
+	case len(oldReactions) == 0 && len(newReactions) > 0:
+		return 1
+	case len(oldReactions) > 0 && len(newReactions) == 0:
+		return -1
+	}
+
+	return 0
+}
+
+// newReactionEvents returns reaction events log entries for user reaction update.
+func newReactionEvents(mru *models.MessageReactionUpdated, deltas map[reactionKey]int) []db.ReactionEvent {
+	var userID, actorChatID *int64
+	if mru.User != nil {
+		userID = &mru.User.ID
+	}
+	if mru.ActorChat != nil {
+		actorChatID = &mru.ActorChat.ID
+	}
+
+	createdAt := time.Unix(int64(mru.Date), 0)
+	events := make([]db.ReactionEvent, 0, len(deltas))
+	for key, delta := range deltas {
+		events = append(events, db.ReactionEvent{
+			ChatID:       mru.Chat.ID,
+			MessageID:    mru.MessageID,
+			UserID:       userID,
+			ActorChatID:  actorChatID,
+			ReactionType: key.Type,
+			Reaction:     key.Reaction,
+			IsAdded:      delta > 0,
+			CreatedAt:    createdAt,
+		})
+	}
+
+	return events
+}
+
+// reactionBreakdown formats reaction counts like "🔥 12 👍 7". Custom emoji are summed under one label.
+func reactionBreakdown(counts []db.MessageReactionCount) string {
+	type labelCount struct {
+		label string
+		count int
+	}
+
+	var (
+		list  []labelCount
+		index = make(map[string]int, len(counts))
+	)
+	for _, c := range counts {
+		label := reactionKey{Type: c.ReactionType, Reaction: c.Reaction}.Label()
+		if i, ok := index[label]; ok {
+			list[i].count += c.Count
+			continue
+		}
+		index[label] = len(list)
+		list = append(list, labelCount{label: label, count: c.Count})
+	}
+
+	sort.SliceStable(list, func(i, j int) bool { return list[i].count > list[j].count })
+
+	parts := make([]string, 0, len(list))
+	for _, lc := range list {
+		parts = append(parts, lc.label+" "+strconv.Itoa(lc.count))
+	}
+
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,123 @@
+package botsrv
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"botsrv/pkg/db"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+const (
+	reactorsOptOut = "optout"
+	reactorsOptIn  = "optin"
+)
+
+// reactorsText builds leaderboard of chat users ranked by reactions they left in period.
+func (bm *BotManager) reactorsText(ctx context.Context, l localizer, cs db.ChatSetting, spec string) (string, error) {
+	period, err := parsePeriod(spec, time.Now(), settingLocation(cs))
+	if err != nil {
+		return "", fmt.Errorf("incorrect period=%q: %w", spec, err)
+	}
+
+	search := &db.ReactionEventSearch{ChatID: &cs.ChatID}
+	if period.Windowed() {
+		search.CreatedFrom = &period.From
+		search.WithCreatedTo(period.To)
+	}
+	search.WithExcludedReactors(cs.ExcludedUserIDs)
+
+	stats, err := bm.cr.ReactorStatsByFilters(ctx, search, db.Pager{PageSize: cs.TopN})
+	if err != nil {
+		return "", fmt.Errorf("fetch reactors: %w", err)
+	}
+
+	res := "<b>" + escapeHTML(l.T("reactors.header", period.title(l))) + "</b>"
+	if len(stats) == 0 {
+		return res + "\n\n" + l.T("no_reactions"), nil
+	}
+
+	for i, stat := range stats {
+		name := "#" + strconv.FormatInt(stat.UserID, 10)
+		if stat.UserName != nil {
+			name = *stat.UserName
+		}
+
+		res += "\n\n" + placeMark(i) + " <b>" + escapeHTML(name) + "</b>\n" +
+			l.N("reactions", stat.ReactionsCount) + ", " + l.N("messages", stat.MessagesCount)
+		if stat.FavoriteReaction != "" {
+			favorite := reactionKey{Type: stat.FavoriteReactionType, Reaction: stat.FavoriteReaction}.Label()
+			res += ", " + escapeHTML(l.T("reactors.favorite", favorite))
+		}
+	}
+
+	return res + "\n\n<i>" + escapeHTML(l.T("reactors.hint", reactorsCommand)) + "</i>", nil
+}
+
+// setReactorOptOut hides the user from reactors leaderboards of all chats or returns the user back.
+func (bm *BotManager) setReactorOptOut(ctx context.Context, u *models.User, optOut bool) error {
+	user := newUser(u)
+	if optOut {
+		user.OptedOutAt = &user.UpdatedAt
+	}
+
+	return bm.cr.SetUserOptOut(ctx, user)
+}
+
+// TopReactorsHandler sends leaderboard of the most generous users for period from command arguments like
+// "/topreactors week", chat default period is used without arguments. "/topreactors optout" hides the sender from
+// leaderboards of all chats, "/topreactors optin" returns the sender back, both work in private chat too.
+func (bm *BotManager) TopReactorsHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	m := update.Message
+	if m == nil || m.From == nil {
+		return
+	}
+
+	cs, err := bm.chatSetting(ctx, m.Chat.ID)
+	if err != nil {
+		bm.Errorf("%v", err)
+		return
+	}
+	l := chatLocalizer(cs, m.From)
+
+	params := &bot.SendMessageParams{ChatID: m.Chat.ID, MessageThreadID: m.MessageThreadID}
+	spec := cs.Period
+	if args := strings.Fields(m.Text)[1:]; len(args) > 0 {
+		spec = strings.ToLower(strings.Join(args, ""))
+	}
+
+	switch {
+	case spec == reactorsOptOut || spec == reactorsOptIn:
+		if err = bm.setReactorOptOut(ctx, m.From, spec == reactorsOptOut); err != nil {
+			bm.Errorf("Failed to set reactor opt-out: %v", err)
+			return
+		}
+		params.Text = l.T("reactors.opted_in")
+		if spec == reactorsOptOut {
+			params.Text = l.T("reactors.opted_out")
+		}
+	case !isTrackedChat(m.Chat):
+		return
+	default:
+		if _, err = parsePeriod(spec, time.Now(), settingLocation(cs)); err != nil {
+			params.Text = l.E(err) + "\n\n" + l.T("period.help", reactorsCommand)
+			break
+		}
+
+		if params.Text, err = bm.reactorsText(ctx, l, cs, spec); err != nil {
+			bm.Errorf("Failed to build reactors: %v", err)
+			return
+		}
+		params.ParseMode, params.LinkPreviewOptions = models.ParseModeHTML, disabledLinkPreview
+	}
+
+	if _, err = b.SendMessage(ctx, params); err != nil {
+		bm.Errorf("%v", err)
+		return
+	}
+}
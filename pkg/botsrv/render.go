@@ -0,0 +1,144 @@
+package botsrv
+
+import (
+	"html"
+	"strconv"
+	"strings"
+
+	"github.com/go-telegram/bot/models"
+)
+
+const (
+	// maxMessageLength is telegram limit of message text length in UTF-16 code units.
+	maxMessageLength = 4096
+)
+
+// medals mark the first places of digest.
+var medals = []string{"🥇", "🥈", "🥉"}
+
+// disabledLinkPreview disables link previews of digest messages.
+var disabledLinkPreview = &models.LinkPreviewOptions{IsDisabled: pointer(true)}
+
+// escapeHTML escapes text for telegram HTML parse mode.
+func escapeHTML(s string) string {
+	return html.EscapeString(s)
+}
+
+// htmlLink returns HTML link with escaped text, plain escaped text if url is empty.
+func htmlLink(url, text string) string {
+	if url == "" {
+		return escapeHTML(text)
+	}
+
+	return `<a href="` + escapeHTML(url) + `">` + escapeHTML(text) + "</a>"
+}
+
+// placeMark returns medal for the first places and number for others, i is zero based.
+func placeMark(i int) string {
+	if i < len(medals) {
+		return medals[i]
+	}
+
+	return strconv.Itoa(i+1) + "."
+}
+
+// textLength returns text length in UTF-16 code units as telegram counts it.
+func textLength(s string) int {
+	n := 0
+	for _, r := range s {
+		n++
+		if r >= 0x10000 {
+			n++
+		}
+	}
+
+	return n
+}
+
+// digestHTML is digest rendered in HTML: header and blocks of messages. Blocks are never split, so each part
+// of long digest is valid HTML.
+type digestHTML struct {
+	header string
+	blocks []string
+	// replyTo is message of each block without permalink, new digest messages reply to it instead, 0 for none.
+	replyTo []int
+}
+
+// digestMessage is one message of sent digest, ReplyTo is the message it replies to, 0 for none.
+type digestMessage struct {
+	Text    string
+	ReplyTo int
+}
+
+// add appends block of the message, replyTo is set for messages without permalink.
+func (d *digestHTML) add(block string, replyTo int) {
+	d.blocks = append(d.blocks, block)
+	d.replyTo = append(d.replyTo, replyTo)
+}
+
+// HasReplies returns true if some blocks should be sent as replies.
+func (d digestHTML) HasReplies() bool {
+	for _, id := range d.replyTo {
+		if id != 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ReplyParameters returns reply to the message, nil for 0.
+func (m digestMessage) ReplyParameters() *models.ReplyParameters {
+	if m.ReplyTo == 0 {
+		return nil
+	}
+
+	return &models.ReplyParameters{MessageID: m.ReplyTo, AllowSendingWithoutReply: true}
+}
+
+// String returns the whole digest.
+func (d digestHTML) String() string {
+	return d.header + strings.Join(d.blocks, "")
+}
+
+// Split splits digest into messages not longer than limit. Header is kept in the first message. Blocks with reply
+// are sent in their own messages replying to the messages they describe, the first one is sent with header.
+func (d digestHTML) Split(limit int) []digestMessage {
+	res := []digestMessage{{Text: d.header}}
+	for i, block := range d.blocks {
+		replyTo := 0
+		if i < len(d.replyTo) {
+			replyTo = d.replyTo[i]
+		}
+
+		last := &res[len(res)-1]
+		fits := textLength(last.Text)+textLength(block) <= limit
+		switch {
+		case fits && i == 0:
+			last.ReplyTo = replyTo
+		case !fits || replyTo != 0 || last.ReplyTo != 0:
+			res = append(res, digestMessage{Text: strings.TrimLeft(block, "\n"), ReplyTo: replyTo})
+			continue
+		}
+		last.Text += block
+	}
+
+	return res
+}
+
+// Truncate returns digest that fits limit, dropped blocks are replaced with count of the omitted messages.
+func (d digestHTML) Truncate(l localizer, limit int) string {
+	res := d.header
+	for i, block := range d.blocks {
+		more := ""
+		if rest := len(d.blocks) - i - 1; rest > 0 {
+			more = "\n\n" + l.N("digest.more", rest)
+		}
+		if textLength(res)+textLength(block)+textLength(more) > limit {
+			return res + "\n\n" + l.N("digest.more", len(d.blocks)-i)
+		}
+		res += block
+	}
+
+	return res
+}
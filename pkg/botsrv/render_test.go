@@ -0,0 +1,210 @@
+package botsrv
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"botsrv/pkg/db"
+)
+
+var update = flag.Bool("update", false, "update golden files in testdata")
+
+// assertGolden compares got with testdata/name, -update rewrites the file.
+func assertGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.MkdirAll("testdata", 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read golden file: %v, run go test -update to create it", err)
+	}
+	if bytes.Equal(got, want) {
+		return
+	}
+
+	if utf8.Valid(got) {
+		t.Errorf("%s mismatch:\ngot:\n%s\nwant:\n%s", name, got, want)
+	} else {
+		t.Errorf("%s mismatch, run go test -update and inspect the file", name)
+	}
+}
+
+// testDigest returns topic digest of n messages with HTML special chars in topic title, previews and links.
+// Even places have permalinks, odd ones don't.
+func testDigest(l localizer, n int) digestHTML {
+	topics := map[int]string{7: `Q&A <"dev">`}
+	d := digestHTML{header: "<b>" + l.T("digest.header_topic", l.T(digestSorts[digestSortReactions].Title),
+		escapeHTML(topicTitle(l, topics, 7)), l.T("period.week")) + "</b>"}
+
+	for i := 0; i < n; i++ {
+		text, author := fmt.Sprintf(`<b>not bold</b> & "quoted" #%d`, i+1), `Tom & "Jerry"`
+		link := ""
+		if i%2 == 0 {
+			link = fmt.Sprintf("https://t.me/c/123/%d?thread=7&x=<y>", i+1)
+		}
+
+		preview := messagePreview(l, &db.Message{Text: &text, AuthorName: &author})
+		d.add("\n\n"+placeMark(i)+" "+htmlLink(link, preview)+"\n"+l.N("reactions", n-i), 0)
+	}
+
+	return d
+}
+
+func TestEscapeHTML(t *testing.T) {
+	tests := []struct {
+		name string
+		url  string
+		text string
+		want string
+	}{
+		{name: "plain", text: "hello", want: "hello"},
+		{name: "special chars", text: `<b>Tom & "Jerry"</b>`, want: "&lt;b&gt;Tom &amp; &#34;Jerry&#34;&lt;/b&gt;"},
+		{name: "link", url: "https://t.me/c/1/2?thread=3&x=<y>", text: `a<b>&"c"`,
+			want: `<a href="https://t.me/c/1/2?thread=3&amp;x=&lt;y&gt;">a&lt;b&gt;&amp;&#34;c&#34;</a>`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := htmlLink(tt.url, tt.text); got != tt.want {
+				t.Errorf("htmlLink() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlaceMark(t *testing.T) {
+	want := []string{"🥇", "🥈", "🥉", "4.", "5.", "10."}
+	for i, n := range []int{0, 1, 2, 3, 4, 9} {
+		if got := placeMark(n); got != want[i] {
+			t.Errorf("placeMark(%d) = %q, want %q", n, got, want[i])
+		}
+	}
+}
+
+func TestTextLength(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{text: "abc", want: 3},
+		{text: "привет", want: 6},
+		{text: "👍", want: 2},
+		{text: "👍🏽", want: 4},
+		{text: "❤️", want: 2},
+		{text: "👨‍👩‍👧", want: 8},
+	}
+
+	for _, tt := range tests {
+		if got := textLength(tt.text); got != tt.want {
+			t.Errorf("textLength(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestDigestHTML(t *testing.T) {
+	for _, lang := range []string{langRu, langEn} {
+		t.Run(lang, func(t *testing.T) {
+			assertGolden(t, "digest_"+lang+".golden", []byte(testDigest(newLocalizer(lang), 5).String()))
+		})
+	}
+}
+
+func TestDigestHTML_Split(t *testing.T) {
+	header := "<b>h</b>"
+	// header and block take exactly maxMessageLength code units, but more bytes
+	fits := "\n\n" + strings.Repeat("👍", (maxMessageLength-textLength(header)-2)/2)
+	overflows := fits + "👍"
+
+	tests := []struct {
+		name   string
+		blocks []string
+		want   []int
+	}{
+		{name: "exact limit", blocks: []string{fits}, want: []int{maxMessageLength}},
+		{name: "next block", blocks: []string{fits, "\n\n👍"}, want: []int{maxMessageLength, 2}},
+		{name: "over limit", blocks: []string{overflows}, want: []int{textLength(header), textLength(overflows) - 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parts := digestHTML{header: header, blocks: tt.blocks}.Split(maxMessageLength)
+
+			got := make([]int, 0, len(parts))
+			for _, part := range parts {
+				got = append(got, textLength(part.Text))
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("Split() part lengths = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if len(fits) <= maxMessageLength {
+		t.Errorf("test block must be longer than limit in bytes, got %d", len(fits))
+	}
+
+	var parts []string
+	for _, m := range testDigest(newLocalizer(langEn), 5).Split(300) {
+		parts = append(parts, m.Text)
+	}
+	assertGolden(t, "digest_split.golden", []byte(strings.Join(parts, "\n--- next message ---\n")))
+}
+
+func TestDigestHTML_SplitReplies(t *testing.T) {
+	d := digestHTML{header: "h"}
+	d.add("\n\n1", 10)
+	d.add("\n\n2", 0)
+	d.add("\n\n3", 0)
+	d.add("\n\n4", 30)
+	d.add("\n\n5", 0)
+
+	want := []digestMessage{{Text: "h\n\n1", ReplyTo: 10}, {Text: "2\n\n3"}, {Text: "4", ReplyTo: 30}, {Text: "5"}}
+	if got := d.Split(maxMessageLength); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Split() = %q, want %q", got, want)
+	}
+	if !d.HasReplies() {
+		t.Error("HasReplies() = false, want true")
+	}
+}
+
+func TestDigestHTML_Truncate(t *testing.T) {
+	for _, lang := range []string{langRu, langEn} {
+		t.Run(lang, func(t *testing.T) {
+			l := newLocalizer(lang)
+
+			// limits leave 1, 2, 5, 21 and 22 omitted messages for plural forms
+			var res []string
+			d := testDigest(l, 25)
+			for _, omitted := range []int{1, 2, 5, 21, 22} {
+				// the limit fits header, kept blocks and omitted count exactly
+				kept := digestHTML{header: d.header, blocks: d.blocks[:len(d.blocks)-omitted]}
+				more := "\n\n" + l.N("digest.more", omitted)
+				limit := textLength(kept.String() + more)
+
+				if got := d.Truncate(l, limit); got != kept.String()+more {
+					t.Errorf("Truncate(%d) = %q, want %d omitted", limit, got, omitted)
+				}
+				res = append(res, d.Truncate(l, limit))
+			}
+			res = append(res, d.Truncate(l, textLength(d.String())))
+
+			assertGolden(t, "digest_truncate_"+lang+".golden", []byte(strings.Join(res, "\n--- next limit ---\n")))
+		})
+	}
+}
@@ -0,0 +1,429 @@
+package botsrv
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"botsrv/pkg/db"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+const (
+	schedulerInterval = 30 * time.Second
+
+	scheduleDaily  = "daily"
+	scheduleWeekly = "weekly"
+	scheduleCron   = "cron"
+	scheduleDelete = "delete"
+)
+
+var cronWeekdays = map[string]int{
+	"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	"вс": 0, "пн": 1, "вт": 2, "ср": 3, "чт": 4, "пт": 5, "сб": 6,
+}
+
+// scheduleLockName returns name of DB lock for digest schedule run.
+func scheduleLockName(id int) string {
+	return "digestSchedule:" + strconv.Itoa(id)
+}
+
+// parseClock parses time of day like "21:00" into minutes since midnight.
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, newInputError("schedule.err.time", s)
+	}
+
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// formatClock formats minutes since midnight like "08:00".
+func formatClock(minutes int) string {
+	return fmt.Sprintf("%02d:%02d", minutes/60, minutes%60)
+}
+
+// parseSchedule parses /schedule command arguments into new schedule. Chat settings are used as defaults.
+func parseSchedule(args []string, cs db.ChatSetting) (*db.DigestSchedule, error) {
+	ds := &db.DigestSchedule{Timezone: cs.Timezone, Period: cs.Period, Sort: cs.Sort}
+	if len(args) == 0 {
+		return nil, newInputError("schedule.err.empty")
+	}
+
+	var rest []string
+	switch args[0] {
+	case scheduleDaily:
+		if len(args) < 2 {
+			return nil, newInputError("schedule.err.time_required")
+		}
+		clock, err := parseClock(args[1])
+		if err != nil {
+			return nil, err
+		}
+		ds.Cron = fmt.Sprintf("%d %d * * *", clock%60, clock/60)
+		rest = args[2:]
+	case scheduleWeekly:
+		if len(args) < 3 {
+			return nil, newInputError("schedule.err.weekday_required")
+		}
+		day, ok := cronWeekdays[strings.ToLower(args[1])]
+		if !ok {
+			return nil, newInputError("schedule.err.weekday", args[1])
+		}
+		clock, err := parseClock(args[2])
+		if err != nil {
+			return nil, err
+		}
+		ds.Cron = fmt.Sprintf("%d %d * * %d", clock%60, clock/60, day)
+		ds.Period = "week"
+		rest = args[3:]
+	case scheduleCron:
+		if len(args) < 6 {
+			return nil, newInputError("schedule.err.cron_fields")
+		}
+		ds.Cron = strings.Join(args[1:6], " ")
+		rest = args[6:]
+	default:
+		return nil, newInputError("schedule.err.unknown", args[0])
+	}
+
+	for _, opt := range rest {
+		key, value, ok := strings.Cut(opt, "=")
+		if !ok {
+			return nil, newInputError("schedule.err.option", opt)
+		}
+
+		switch key {
+		case "tz":
+			ds.Timezone = value
+		case "period":
+			ds.Period = value
+		case "sort":
+			ds.Sort = value
+		case "quiet":
+			from, to, ok := strings.Cut(value, "-")
+			if !ok {
+				return nil, newInputError("schedule.err.quiet", value)
+			}
+			qf, err := parseClock(from)
+			if err != nil {
+				return nil, err
+			}
+			qt, err := parseClock(to)
+			if err != nil {
+				return nil, err
+			}
+			ds.QuietFrom, ds.QuietTo = &qf, &qt
+		default:
+			return nil, newInputError("schedule.err.unknown_option", key)
+		}
+	}
+
+	if _, err := parseCron(ds.Cron); err != nil {
+		return nil, newInputError("schedule.err.cron", ds.Cron)
+	}
+	loc, err := time.LoadLocation(ds.Timezone)
+	if err != nil {
+		return nil, newInputError("schedule.err.timezone", ds.Timezone)
+	}
+	if _, err = parsePeriod(ds.Period, time.Now(), loc); err != nil {
+		return nil, err
+	}
+	if _, ok := digestSorts[ds.Sort]; !ok {
+		return nil, newInputError("schedule.err.sort", ds.Sort)
+	}
+
+	return ds, nil
+}
+
+// inQuietHours returns true if t falls into quiet hours of the schedule. Quiet hours may span midnight.
+func inQuietHours(ds db.DigestSchedule, t time.Time) bool {
+	if ds.QuietFrom == nil || ds.QuietTo == nil {
+		return false
+	}
+
+	m, from, to := t.Hour()*60+t.Minute(), *ds.QuietFrom, *ds.QuietTo
+	if from <= to {
+		return m >= from && m < to
+	}
+
+	return m >= from || m < to
+}
+
+// quietHoursEnd returns the end of quiet hours that t falls into.
+func quietHoursEnd(ds db.DigestSchedule, t time.Time) time.Time {
+	end := time.Date(t.Year(), t.Month(), t.Day(), 0, *ds.QuietTo, 0, 0, t.Location())
+	if !end.After(t) {
+		end = time.Date(t.Year(), t.Month(), t.Day()+1, 0, *ds.QuietTo, 0, 0, t.Location())
+	}
+
+	return end
+}
+
+// nextScheduleRun returns the first schedule run after t in schedule timezone. Runs in quiet hours are postponed
+// until quiet hours end.
+func nextScheduleRun(ds db.DigestSchedule, t time.Time) (time.Time, error) {
+	loc, err := time.LoadLocation(ds.Timezone)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	cs, err := parseCron(ds.Cron)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	next := cs.Next(t.In(loc))
+	if next.IsZero() {
+		return next, fmt.Errorf("cron %q never matches", ds.Cron)
+	}
+	if inQuietHours(ds, next) {
+		next = quietHoursEnd(ds, next)
+	}
+
+	return next, nil
+}
+
+// scheduleDescription returns human readable schedule description for schedules list.
+func scheduleDescription(l localizer, ds db.DigestSchedule) string {
+	s := l.T("schedule.item", ds.ID, ds.Cron, ds.Timezone,
+		periodTitle(l, ds.Period), l.T(digestSorts[ds.Sort].Title))
+	if ds.QuietFrom != nil && ds.QuietTo != nil {
+		s += l.T("schedule.quiet", formatClock(*ds.QuietFrom), formatClock(*ds.QuietTo))
+	}
+
+	return s + l.T("schedule.next", ds.NextRunAt.In(scheduleLocation(ds)).Format("02.01.2006 15:04"))
+}
+
+// scheduleLocation returns schedule timezone, UTC for invalid one.
+func scheduleLocation(ds db.DigestSchedule) *time.Location {
+	loc, err := time.LoadLocation(ds.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+
+	return loc
+}
+
+// ScheduleHandler lists, creates and deletes digest schedules of the chat. Only chat admins can change schedules.
+func (bm *BotManager) ScheduleHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	if update.Message == nil || !isTrackedChat(update.Message.Chat) {
+		return
+	}
+
+	text, err := bm.schedule(ctx, b, update.Message)
+	if err != nil {
+		bm.Errorf("%v", err)
+		return
+	}
+
+	_, err = b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:          update.Message.Chat.ID,
+		Text:            text,
+		MessageThreadID: update.Message.MessageThreadID,
+	})
+	if err != nil {
+		bm.Errorf("%v", err)
+		return
+	}
+}
+
+// schedule executes /schedule command and returns reply text.
+func (bm *BotManager) schedule(ctx context.Context, b *bot.Bot, m *models.Message) (string, error) {
+	cs, err := bm.chatSetting(ctx, m.Chat.ID)
+	if err != nil {
+		return "", err
+	}
+	l := chatLocalizer(cs, m.From)
+
+	args := strings.Fields(m.Text)[1:]
+	if len(args) == 0 {
+		list, err := bm.cr.DigestSchedulesByFilters(ctx, &db.DigestScheduleSearch{ChatID: &m.Chat.ID}, db.PagerNoLimit)
+		if err != nil {
+			return "", fmt.Errorf("fetch digest schedules: %w", err)
+		}
+
+		text := l.T("schedule.help")
+		for i, ds := range list {
+			if i == 0 {
+				text += "\n\n" + l.T("schedule.current")
+			}
+			text += "\n" + scheduleDescription(l, ds)
+		}
+
+		return text, nil
+	}
+
+	isAdmin, err := bm.isChatAdmin(ctx, b, m)
+	if err != nil {
+		return "", fmt.Errorf("check chat admin: %w", err)
+	} else if !isAdmin {
+		return l.T("schedule.admin_only"), nil
+	}
+
+	if args[0] == scheduleDelete {
+		id, err := strconv.Atoi(strings.TrimPrefix(strings.Join(args[1:], ""), "#"))
+		if err != nil {
+			return l.T("schedule.delete"), nil
+		}
+
+		deleted, err := bm.deleteSchedule(ctx, m.Chat.ID, id)
+		if err != nil {
+			return "", fmt.Errorf("delete digest schedule: %w", err)
+		} else if !deleted {
+			return l.T("schedule.not_found"), nil
+		}
+
+		return l.T("schedule.deleted"), nil
+	}
+
+	ds, err := parseSchedule(args, cs)
+	if err != nil {
+		return l.E(err) + "\n\n" + l.T("schedule.help"), nil
+	}
+
+	ds.ChatID = m.Chat.ID
+	if m.IsTopicMessage && m.MessageThreadID != 0 {
+		ds.ThreadID = &m.MessageThreadID
+	}
+	if ds.NextRunAt, err = nextScheduleRun(*ds, time.Now()); err != nil {
+		return l.E(newInputError("schedule.err.never", ds.Cron)), nil
+	}
+
+	if err = bm.ensureChat(ctx, m.Chat); err != nil {
+		return "", fmt.Errorf("save chat: %w", err)
+	}
+	if ds, err = bm.cr.AddDigestSchedule(ctx, ds); err != nil {
+		return "", fmt.Errorf("add digest schedule: %w", err)
+	}
+
+	return l.T("schedule.added", scheduleDescription(l, *ds)), nil
+}
+
+// deleteSchedule deletes schedule of the chat under schedule lock, so it is not deleted during posting.
+func (bm *BotManager) deleteSchedule(ctx context.Context, chatID int64, id int) (deleted bool, err error) {
+	err = bm.dbo.RunInLock(ctx, scheduleLockName(id), func(tx *pg.Tx) error {
+		cr := bm.cr.WithTransaction(tx)
+		ds, err := cr.OneDigestSchedule(ctx, &db.DigestScheduleSearch{ID: &id, ChatID: &chatID})
+		if err != nil || ds == nil {
+			return err
+		}
+
+		deleted, err = cr.DeleteDigestSchedule(ctx, id)
+		return err
+	})
+
+	return
+}
+
+// RunDigestScheduler posts scheduled digests until ctx is done.
+func (bm *BotManager) RunDigestScheduler(ctx context.Context, b *bot.Bot) {
+	ticker := time.NewTicker(schedulerInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := bm.runDueSchedules(ctx, b); err != nil {
+			bm.Errorf("run digest schedules err=%q", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (bm *BotManager) runDueSchedules(ctx context.Context, b *bot.Bot) error {
+	now := time.Now()
+	list, err := bm.cr.DigestSchedulesByFilters(ctx, &db.DigestScheduleSearch{NextRunTo: &now}, db.PagerNoLimit)
+	if err != nil {
+		return err
+	}
+
+	for _, ds := range list {
+		if err = bm.runSchedule(ctx, b, ds.ID, now); err != nil {
+			bm.Errorf("run digest schedule id=%d err=%q", ds.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// runSchedule claims due schedule by moving it to the next run in transaction under schedule lock, then posts
+// digest after commit. Schedule is re-read under lock, so replicas don't post it twice. Lock and DB connection are
+// not held while sending, and failed or partially sent digest is not retried, so messages are never re-posted.
+// Runs missed while app was down are posted once on start.
+func (bm *BotManager) runSchedule(ctx context.Context, b *bot.Bot, id int, now time.Time) error {
+	var (
+		chat *db.Chat
+		ds   *db.DigestSchedule
+	)
+	err := bm.dbo.RunInLock(ctx, scheduleLockName(id), func(tx *pg.Tx) (err error) {
+		cr := bm.cr.WithTransaction(tx)
+		ds, err = cr.DigestScheduleByID(ctx, id)
+		if err != nil || ds == nil || ds.NextRunAt.After(now) {
+			ds = nil
+			return err
+		}
+
+		// postpone runs missed during quiet hours
+		if local := now.In(scheduleLocation(*ds)); inQuietHours(*ds, local) {
+			ds.NextRunAt = quietHoursEnd(*ds, local)
+			_, err = cr.UpdateDigestSchedule(ctx, ds)
+			return err
+		}
+
+		if ds.NextRunAt, err = nextScheduleRun(*ds, now); err != nil {
+			return err
+		}
+
+		if chat, err = cr.ChatByID(ctx, ds.ChatID); err != nil {
+			return err
+		}
+
+		// skip chats bot was removed from
+		if chat != nil && chat.LeftAt != nil {
+			chat = nil
+		}
+		if chat != nil {
+			ds.LastRunAt = &now
+		}
+		_, err = cr.UpdateDigestSchedule(ctx, ds)
+		return err
+	})
+	if err != nil || chat == nil {
+		return err
+	}
+
+	return bm.postScheduledDigest(ctx, b, chatModel(chat), *ds)
+}
+
+// postScheduledDigest sends digest over all forum topics to schedule target topic.
+func (bm *BotManager) postScheduledDigest(ctx context.Context, b *bot.Bot, chat models.Chat, ds db.DigestSchedule) error {
+	threadID := 0
+	if ds.ThreadID != nil {
+		threadID = *ds.ThreadID
+	}
+	req := digestRequest{Period: ds.Period, Sort: ds.Sort, AllTopics: chat.IsForum}
+
+	cs, err := bm.chatSetting(ctx, chat.ID)
+	if err != nil {
+		return err
+	}
+	// calendar periods are taken in schedule timezone
+	cs.Timezone = ds.Timezone
+	l := chatLocalizer(cs, nil)
+
+	digest, err := bm.digestText(ctx, l, cs, chat, threadID, req)
+	if err != nil {
+		return err
+	}
+
+	return bm.sendDigest(ctx, b, l, chat, threadID, req, digest)
+}
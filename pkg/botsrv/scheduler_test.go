@@ -0,0 +1,169 @@
+package botsrv
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"botsrv/pkg/db"
+)
+
+func TestParseSchedule(t *testing.T) {
+	cs := db.ChatSetting{Timezone: "Europe/Berlin", Period: "day", Sort: digestSortReactions}
+
+	tests := []struct {
+		name    string
+		args    []string
+		want    db.DigestSchedule
+		quiet   [2]int
+		wantErr string
+	}{
+		{name: "daily", args: []string{"daily", "21:00"},
+			want: db.DigestSchedule{Cron: "0 21 * * *", Timezone: "Europe/Berlin", Period: "day", Sort: digestSortReactions}},
+		{name: "weekly", args: []string{"weekly", "Fri", "18:30"},
+			want: db.DigestSchedule{Cron: "30 18 * * 5", Timezone: "Europe/Berlin", Period: "week", Sort: digestSortReactions}},
+		{name: "weekly russian weekday", args: []string{"weekly", "вс", "09:05"},
+			want: db.DigestSchedule{Cron: "5 9 * * 0", Timezone: "Europe/Berlin", Period: "week", Sort: digestSortReactions}},
+		{name: "cron with options", args: []string{"cron", "0", "9", "*", "*", "1-5", "tz=Asia/Kolkata", "period=3d", "sort=hot", "quiet=22:00-08:00"},
+			want:  db.DigestSchedule{Cron: "0 9 * * 1-5", Timezone: "Asia/Kolkata", Period: "3d", Sort: digestSortHot},
+			quiet: [2]int{22 * 60, 8 * 60}},
+		{name: "empty", wantErr: "schedule.err.empty"},
+		{name: "unknown kind", args: []string{"hourly"}, wantErr: "schedule.err.unknown"},
+		{name: "daily without time", args: []string{"daily"}, wantErr: "schedule.err.time_required"},
+		{name: "invalid time", args: []string{"daily", "25:00"}, wantErr: "schedule.err.time"},
+		{name: "weekly without time", args: []string{"weekly", "mon"}, wantErr: "schedule.err.weekday_required"},
+		{name: "invalid weekday", args: []string{"weekly", "someday", "10:00"}, wantErr: "schedule.err.weekday"},
+		{name: "short cron", args: []string{"cron", "0", "9", "*", "*"}, wantErr: "schedule.err.cron_fields"},
+		{name: "invalid cron", args: []string{"cron", "0", "24", "*", "*", "*"}, wantErr: "schedule.err.cron"},
+		{name: "option without value", args: []string{"daily", "21:00", "quiet"}, wantErr: "schedule.err.option"},
+		{name: "unknown option", args: []string{"daily", "21:00", "lang=en"}, wantErr: "schedule.err.unknown_option"},
+		{name: "invalid quiet hours", args: []string{"daily", "21:00", "quiet=22:00"}, wantErr: "schedule.err.quiet"},
+		{name: "invalid quiet time", args: []string{"daily", "21:00", "quiet=22:00-8"}, wantErr: "schedule.err.time"},
+		{name: "invalid timezone", args: []string{"daily", "21:00", "tz=Mars/Olympus"}, wantErr: "schedule.err.timezone"},
+		{name: "invalid period", args: []string{"daily", "21:00", "period=fortnight"}, wantErr: "period.err.unknown"},
+		{name: "invalid sort", args: []string{"daily", "21:00", "sort=random"}, wantErr: "schedule.err.sort"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseSchedule(tt.args, cs)
+			if tt.wantErr != "" {
+				var ie inputError
+				if !errors.As(err, &ie) || ie.Key != tt.wantErr {
+					t.Fatalf("parseSchedule() err = %v, want %s", err, tt.wantErr)
+				}
+				return
+			} else if err != nil {
+				t.Fatal(err)
+			}
+
+			if got.Cron != tt.want.Cron || got.Timezone != tt.want.Timezone || got.Period != tt.want.Period || got.Sort != tt.want.Sort {
+				t.Errorf("parseSchedule() = %q %s %s %s, want %q %s %s %s", got.Cron, got.Timezone, got.Period, got.Sort,
+					tt.want.Cron, tt.want.Timezone, tt.want.Period, tt.want.Sort)
+			}
+
+			var quiet [2]int
+			if got.QuietFrom != nil && got.QuietTo != nil {
+				quiet = [2]int{*got.QuietFrom, *got.QuietTo}
+			}
+			if quiet != tt.quiet {
+				t.Errorf("parseSchedule() quiet hours = %v, want %v", quiet, tt.quiet)
+			}
+		})
+	}
+}
+
+func TestNextScheduleRun(t *testing.T) {
+	loc, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	date := func(month time.Month, day, hour, min int) time.Time {
+		return time.Date(2025, month, day, hour, min, 0, 0, loc)
+	}
+	quiet := func(from, to int) db.DigestSchedule {
+		return db.DigestSchedule{QuietFrom: pointer(from * 60), QuietTo: pointer(to * 60)}
+	}
+
+	tests := []struct {
+		name  string
+		cron  string
+		quiet db.DigestSchedule
+		from  time.Time
+		want  time.Time
+	}{
+		{name: "keeps local time after spring forward", cron: "0 21 * * *", from: date(3, 29, 22, 0), want: date(3, 30, 21, 0)},
+		{name: "keeps local time after fall back", cron: "0 21 * * *", from: date(10, 25, 22, 0), want: date(10, 26, 21, 0)},
+		{name: "skipped hour on spring forward", cron: "30 2 * * *", from: date(3, 29, 3, 0), want: date(3, 31, 2, 30)},
+		// repeated 02:30 resolves to the second one, in CET
+		{name: "repeated hour on fall back", cron: "30 2 * * *", from: date(10, 26, 0, 0),
+			want: time.Date(2025, 10, 26, 1, 30, 0, 0, time.UTC)},
+		{name: "repeated hour on fall back runs once", cron: "30 2 * * *", from: time.Date(2025, 10, 26, 1, 30, 0, 0, time.UTC),
+			want: date(10, 27, 2, 30)},
+		{name: "outside quiet hours", cron: "0 12 * * *", quiet: quiet(22, 8), from: date(3, 3, 10, 0), want: date(3, 3, 12, 0)},
+		{name: "quiet hours before midnight", cron: "0 23 * * *", quiet: quiet(22, 8), from: date(3, 3, 10, 0), want: date(3, 4, 8, 0)},
+		{name: "quiet hours after midnight", cron: "30 7 * * *", quiet: quiet(22, 8), from: date(3, 3, 10, 0), want: date(3, 4, 8, 0)},
+		{name: "quiet hours end on spring forward day", cron: "0 23 * * *", quiet: quiet(22, 8), from: date(3, 29, 10, 0),
+			want: date(3, 30, 8, 0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ds := tt.quiet
+			ds.Cron, ds.Timezone = tt.cron, loc.String()
+
+			got, err := nextScheduleRun(ds, tt.from)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("nextScheduleRun() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err = nextScheduleRun(db.DigestSchedule{Cron: "0 0 30 2 *", Timezone: "UTC"}, date(3, 1, 0, 0)); err == nil {
+		t.Error("nextScheduleRun() err = nil for never matching cron")
+	}
+}
+
+func TestInQuietHours(t *testing.T) {
+	clock := func(hour, min int) time.Time { return time.Date(2025, 3, 3, hour, min, 0, 0, time.UTC) }
+
+	tests := []struct {
+		name     string
+		from, to int
+		at       time.Time
+		want     bool
+		wantEnd  time.Time
+	}{
+		{name: "before wrapping window", from: 22 * 60, to: 8 * 60, at: clock(21, 59)},
+		{name: "wrapping window start", from: 22 * 60, to: 8 * 60, at: clock(22, 0), want: true, wantEnd: clock(32, 0)},
+		{name: "before midnight", from: 22 * 60, to: 8 * 60, at: clock(23, 59), want: true, wantEnd: clock(32, 0)},
+		{name: "midnight", from: 22 * 60, to: 8 * 60, at: clock(0, 0), want: true, wantEnd: clock(8, 0)},
+		{name: "after midnight", from: 22 * 60, to: 8 * 60, at: clock(7, 59), want: true, wantEnd: clock(8, 0)},
+		{name: "wrapping window end", from: 22 * 60, to: 8 * 60, at: clock(8, 0)},
+		{name: "inside day window", from: 13 * 60, to: 14 * 60, at: clock(13, 30), want: true, wantEnd: clock(14, 0)},
+		{name: "day window end", from: 13 * 60, to: 14 * 60, at: clock(14, 0)},
+		{name: "empty window", from: 13 * 60, to: 13 * 60, at: clock(13, 0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ds := db.DigestSchedule{QuietFrom: &tt.from, QuietTo: &tt.to}
+			if got := inQuietHours(ds, tt.at); got != tt.want {
+				t.Fatalf("inQuietHours(%s) = %v, want %v", tt.at.Format("15:04"), got, tt.want)
+			}
+			if !tt.want {
+				return
+			}
+			if got := quietHoursEnd(ds, tt.at); !got.Equal(tt.wantEnd) {
+				t.Errorf("quietHoursEnd(%s) = %v, want %v", tt.at.Format("15:04"), got, tt.wantEnd)
+			}
+		})
+	}
+
+	if inQuietHours(db.DigestSchedule{}, clock(23, 0)) {
+		t.Error("inQuietHours() = true without quiet hours")
+	}
+}
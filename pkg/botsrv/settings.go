@@ -0,0 +1,543 @@
+package botsrv
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"botsrv/pkg/db"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+const (
+	settingsMenu          = "menu"
+	settingsTopN          = "top"
+	settingsPeriod        = "period"
+	settingsSort          = "sort"
+	settingsLanguage      = "lang"
+	settingsTimezone      = "tz"
+	settingsExcluded      = "excl"
+	settingsIncludeUser   = "inuser"
+	settingsIncludeThread = "intopic"
+	settingsExcludeBots   = "bots"
+	settingsExcludeAnon   = "anon"
+	settingsClose         = "close"
+	settingsWeight        = "weight"
+
+	settingsExcludeUser   = "user"
+	settingsExcludeThread = "topic"
+
+	defaultTopN     = digestPageSize
+	maxTopN         = 50
+	defaultPeriod   = "day"
+	defaultTimezone = "UTC"
+	// maxReactionWeight limits absolute value of reaction weight.
+	maxReactionWeight = 100
+)
+
+var (
+	topNOptions = []int{5, 10, 15, 20, 30}
+	// periodOrder is the order of digest periods in keyboards.
+	periodOrder     = []string{"hour", "day", "week", "month", "all"}
+	languageOptions = []string{"", langRu, langEn}
+	// languageTitles is language names in their own language, auto language title is translated.
+	languageTitles  = map[string]string{"": "language.auto", langRu: "русский", langEn: "English"}
+	timezoneOptions = []string{"UTC", "Europe/Kaliningrad", "Europe/Moscow", "Europe/Samara", "Asia/Yekaterinburg",
+		"Asia/Omsk", "Asia/Novosibirsk", "Asia/Irkutsk", "Asia/Vladivostok", "Europe/London", "Europe/Berlin", "America/New_York"}
+)
+
+// defaultChatSetting returns settings of chat without stored ones.
+func defaultChatSetting(chatID int64) db.ChatSetting {
+	return db.ChatSetting{
+		ChatID:   chatID,
+		TopN:     defaultTopN,
+		Period:   defaultPeriod,
+		Sort:     digestSortReactions,
+		Timezone: defaultTimezone,
+	}
+}
+
+// chatSetting returns stored chat settings or defaults.
+func (bm *BotManager) chatSetting(ctx context.Context, chatID int64) (db.ChatSetting, error) {
+	cs, err := bm.cr.ChatSettingByID(ctx, chatID)
+	if err != nil {
+		return db.ChatSetting{}, fmt.Errorf("fetch chat settings: %w", err)
+	} else if cs == nil {
+		return defaultChatSetting(chatID), nil
+	}
+
+	return *cs, nil
+}
+
+// validateChatSetting checks chat settings values.
+func validateChatSetting(cs db.ChatSetting) error {
+	if cs.TopN < 1 || cs.TopN > maxTopN {
+		return fmt.Errorf("top size must be between 1 and %d", maxTopN)
+	}
+	if _, ok := reactionPeriods[paternDigest+cs.Period]; !ok {
+		return fmt.Errorf("invalid period %q", cs.Period)
+	}
+	if _, ok := digestSorts[cs.Sort]; !ok {
+		return fmt.Errorf("invalid sort %q", cs.Sort)
+	}
+	if _, ok := languageTitles[settingLanguage(cs)]; !ok {
+		return fmt.Errorf("invalid language %q", settingLanguage(cs))
+	}
+	if _, err := time.LoadLocation(cs.Timezone); err != nil {
+		return newInputError("settings.err.timezone", cs.Timezone)
+	}
+
+	return nil
+}
+
+// settingLanguage returns chat language, empty string for auto.
+func settingLanguage(cs db.ChatSetting) string {
+	if cs.Language == nil {
+		return ""
+	}
+
+	return *cs.Language
+}
+
+// settingLocation returns chat timezone, UTC for invalid one.
+func settingLocation(cs db.ChatSetting) *time.Location {
+	loc, err := time.LoadLocation(cs.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+
+	return loc
+}
+
+// chatReactionSearch returns search of chat message reactions without messages excluded by chat settings.
+func chatReactionSearch(cs db.ChatSetting) *db.MessageReactionSearch {
+	search := (&db.MessageReactionSearch{ChatID: &cs.ChatID}).
+		WithExcludedUsers(cs.ExcludedUserIDs).
+		WithExcludedThreads(cs.ExcludedThreadIDs)
+	if cs.ExcludeBots {
+		search.WithExcludedBots()
+	}
+	if cs.ExcludeAnonymous {
+		search.WithExcludedAnonymous()
+	}
+
+	return search
+}
+
+// saveChatSetting validates and stores chat settings.
+func (bm *BotManager) saveChatSetting(ctx context.Context, cs db.ChatSetting) error {
+	if err := validateChatSetting(cs); err != nil {
+		return err
+	}
+
+	cs.UpdatedAt = time.Now()
+	return bm.cr.SaveChatSetting(ctx, &cs)
+}
+
+// settingsData returns callback data of settings menu action.
+func settingsData(parts ...string) string {
+	return patternSettings + strings.Join(parts, ":")
+}
+
+// settingsText returns settings menu text.
+func settingsText(l localizer, cs db.ChatSetting) string {
+	return l.T("settings.title", cs.TopN, periodTitle(l, cs.Period), l.T(digestSorts[cs.Sort].Title),
+		languageTitle(l, settingLanguage(cs)), cs.Timezone, l.N("users", len(cs.ExcludedUserIDs)), l.N("topics", len(cs.ExcludedThreadIDs)))
+}
+
+// languageTitle returns language name, auto language title is translated.
+func languageTitle(l localizer, lang string) string {
+	if lang == "" {
+		return l.T(languageTitles[lang])
+	}
+
+	return languageTitles[lang]
+}
+
+// settingsOption returns settings option button, current value is marked.
+func settingsOption(text string, current bool, data string) models.InlineKeyboardButton {
+	if current {
+		text = "• " + text
+	}
+
+	return models.InlineKeyboardButton{Text: text, CallbackData: data}
+}
+
+// settingsToggle returns button switching boolean setting, enabled setting is checked.
+func settingsToggle(text string, enabled bool, action string) models.InlineKeyboardButton {
+	mark := "☐ "
+	if enabled {
+		mark = "☑ "
+	}
+
+	return models.InlineKeyboardButton{Text: mark + text, CallbackData: settingsData(action, strconv.FormatBool(!enabled))}
+}
+
+// settingsRows splits buttons into rows of n buttons and adds back button.
+func settingsRows(l localizer, buttons []models.InlineKeyboardButton, n int) *models.InlineKeyboardMarkup {
+	kb := &models.InlineKeyboardMarkup{}
+	for i, button := range buttons {
+		if i%n == 0 {
+			kb.InlineKeyboard = append(kb.InlineKeyboard, nil)
+		}
+		last := len(kb.InlineKeyboard) - 1
+		kb.InlineKeyboard[last] = append(kb.InlineKeyboard[last], button)
+	}
+	kb.InlineKeyboard = append(kb.InlineKeyboard, []models.InlineKeyboardButton{{Text: l.T("settings.back"), CallbackData: settingsData(settingsMenu)}})
+
+	return kb
+}
+
+// settingsKeyboard returns keyboard of settings menu level.
+func settingsKeyboard(l localizer, cs db.ChatSetting, level string, topics map[int]string) *models.InlineKeyboardMarkup {
+	var buttons []models.InlineKeyboardButton
+	switch level {
+	case settingsTopN:
+		for _, n := range topNOptions {
+			buttons = append(buttons, settingsOption(strconv.Itoa(n), n == cs.TopN, settingsData(settingsTopN, strconv.Itoa(n))))
+		}
+		return settingsRows(l, buttons, len(topNOptions))
+	case settingsPeriod:
+		for _, p := range periodOrder {
+			buttons = append(buttons, settingsOption(periodTitle(l, p), p == cs.Period, settingsData(settingsPeriod, p)))
+		}
+		return settingsRows(l, buttons, 3)
+	case settingsSort:
+		for _, s := range digestSortOrder {
+			buttons = append(buttons, settingsOption(l.T(digestSorts[s].Button), s == cs.Sort, settingsData(settingsSort, s)))
+		}
+		return settingsRows(l, buttons, digestSortsPerRow)
+	case settingsLanguage:
+		for _, lang := range languageOptions {
+			buttons = append(buttons, settingsOption(languageTitle(l, lang), lang == settingLanguage(cs), settingsData(settingsLanguage, lang)))
+		}
+		return settingsRows(l, buttons, len(languageOptions))
+	case settingsTimezone:
+		for i, tz := range timezoneOptions {
+			buttons = append(buttons, settingsOption(tz, tz == cs.Timezone, settingsData(settingsTimezone, strconv.Itoa(i))))
+		}
+		return settingsRows(l, buttons, 2)
+	case settingsExcluded:
+		buttons = append(buttons,
+			settingsToggle(l.T("settings.exclude_bots"), cs.ExcludeBots, settingsExcludeBots),
+			settingsToggle(l.T("settings.exclude_anonymous"), cs.ExcludeAnonymous, settingsExcludeAnon),
+		)
+		for _, id := range cs.ExcludedUserIDs {
+			buttons = append(buttons, models.InlineKeyboardButton{
+				Text:         l.T("settings.excluded_user", id),
+				CallbackData: settingsData(settingsIncludeUser, strconv.FormatInt(id, 10)),
+			})
+		}
+		for _, id := range cs.ExcludedThreadIDs {
+			buttons = append(buttons, models.InlineKeyboardButton{
+				Text:         l.T("settings.excluded_topic", topicTitle(l, topics, id)),
+				CallbackData: settingsData(settingsIncludeThread, strconv.Itoa(id)),
+			})
+		}
+		return settingsRows(l, buttons, 1)
+	}
+
+	return &models.InlineKeyboardMarkup{InlineKeyboard: [][]models.InlineKeyboardButton{
+		{
+			{Text: l.T("settings.top", cs.TopN), CallbackData: settingsData(settingsTopN)},
+			{Text: l.T("settings.period", periodTitle(l, cs.Period)), CallbackData: settingsData(settingsPeriod)},
+		},
+		{
+			{Text: l.T("settings.sort"), CallbackData: settingsData(settingsSort)},
+			{Text: l.T("settings.language", languageTitle(l, settingLanguage(cs))), CallbackData: settingsData(settingsLanguage)},
+		},
+		{{Text: l.T("settings.timezone", cs.Timezone), CallbackData: settingsData(settingsTimezone)}},
+		{{Text: l.T("settings.excluded", len(cs.ExcludedUserIDs)+len(cs.ExcludedThreadIDs)), CallbackData: settingsData(settingsExcluded)}},
+		{{Text: l.T("settings.close"), CallbackData: settingsData(settingsClose)}},
+	}}
+}
+
+// applySettingsAction changes chat settings by menu action. Returns menu level to show next.
+func applySettingsAction(cs *db.ChatSetting, action, value string) (string, error) {
+	switch action {
+	case settingsTopN:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return "", err
+		}
+		cs.TopN = n
+	case settingsPeriod:
+		cs.Period = value
+	case settingsSort:
+		cs.Sort = value
+	case settingsLanguage:
+		cs.Language = nil
+		if value != "" {
+			cs.Language = &value
+		}
+	case settingsTimezone:
+		i, err := strconv.Atoi(value)
+		if err != nil || i < 0 || i >= len(timezoneOptions) {
+			return "", fmt.Errorf("invalid timezone option %q", value)
+		}
+		cs.Timezone = timezoneOptions[i]
+	case settingsIncludeUser:
+		id, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return "", err
+		}
+		cs.ExcludedUserIDs = removeValue(cs.ExcludedUserIDs, id)
+		return settingsExcluded, nil
+	case settingsIncludeThread:
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			return "", err
+		}
+		cs.ExcludedThreadIDs = removeValue(cs.ExcludedThreadIDs, id)
+		return settingsExcluded, nil
+	case settingsExcludeBots, settingsExcludeAnon:
+		exclude, err := strconv.ParseBool(value)
+		if err != nil {
+			return "", err
+		}
+		if action == settingsExcludeBots {
+			cs.ExcludeBots = exclude
+		} else {
+			cs.ExcludeAnonymous = exclude
+		}
+		return settingsExcluded, nil
+	default:
+		return "", fmt.Errorf("unknown settings action %q", action)
+	}
+
+	return settingsMenu, nil
+}
+
+// removeValue returns list without v.
+func removeValue[T comparable](list []T, v T) []T {
+	res := make([]T, 0, len(list))
+	for _, item := range list {
+		if item != v {
+			res = append(res, item)
+		}
+	}
+
+	return res
+}
+
+// toggleValue adds v to list or removes it if present. Returns true if value was added.
+func toggleValue[T comparable](list []T, v T) ([]T, bool) {
+	for _, item := range list {
+		if item == v {
+			return removeValue(list, v), false
+		}
+	}
+
+	return append(list, v), true
+}
+
+// SettingsHandler shows chat settings menu or applies text settings commands. Only chat admins can change settings.
+func (bm *BotManager) SettingsHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	m := update.Message
+	if m == nil || !isTrackedChat(m.Chat) {
+		return
+	}
+
+	cs, err := bm.chatSetting(ctx, m.Chat.ID)
+	if err != nil {
+		bm.Errorf("%v", err)
+		return
+	}
+	l := chatLocalizer(cs, m.From)
+
+	params := &bot.SendMessageParams{ChatID: m.Chat.ID, MessageThreadID: m.MessageThreadID}
+	isAdmin, err := bm.isChatAdmin(ctx, b, m)
+	switch {
+	case err != nil:
+		bm.Errorf("check chat admin: %v", err)
+		return
+	case !isAdmin:
+		params.Text = l.T("settings.admin_only")
+	default:
+		if params.Text, err = bm.settingsCommand(ctx, l, cs, m); err != nil {
+			bm.Errorf("%v", err)
+			return
+		}
+
+		if params.Text == "" {
+			params.Text, params.ReplyMarkup = settingsText(l, cs), settingsKeyboard(l, cs, settingsMenu, nil)
+		}
+	}
+
+	if _, err = b.SendMessage(ctx, params); err != nil {
+		bm.Errorf("%v", err)
+		return
+	}
+}
+
+// settingsCommand applies text settings command like "/settings tz Europe/Moscow". Returns empty text for menu.
+func (bm *BotManager) settingsCommand(ctx context.Context, l localizer, cs db.ChatSetting, m *models.Message) (string, error) {
+	args := strings.Fields(m.Text)[1:]
+	if len(args) == 0 {
+		return "", nil
+	}
+
+	var text string
+	switch {
+	case args[0] == settingsTimezone && len(args) == 2:
+		cs.Timezone = args[1]
+		text = l.T("settings.timezone", cs.Timezone)
+	case args[0] == settingsWeight && len(args) == 3:
+		return bm.setReactionWeight(ctx, l, cs.ChatID, m, args[1], args[2])
+	case args[0] == "exclude" && len(args) == 2 && args[1] == settingsExcludeUser:
+		if m.ReplyToMessage == nil || m.ReplyToMessage.From == nil || m.ReplyToMessage.From.IsBot {
+			return l.T("settings.reply_required"), nil
+		}
+
+		added := false
+		cs.ExcludedUserIDs, added = toggleValue(cs.ExcludedUserIDs, m.ReplyToMessage.From.ID)
+		text = l.T("settings.user_included", userName(m.ReplyToMessage.From))
+		if added {
+			text = l.T("settings.user_excluded", userName(m.ReplyToMessage.From))
+		}
+	case args[0] == "exclude" && len(args) == 2 && args[1] == settingsExcludeThread:
+		if !m.IsTopicMessage || m.MessageThreadID == 0 {
+			return l.T("settings.topic_required"), nil
+		}
+
+		added := false
+		cs.ExcludedThreadIDs, added = toggleValue(cs.ExcludedThreadIDs, m.MessageThreadID)
+		text = l.T("settings.topic_included")
+		if added {
+			text = l.T("settings.topic_excluded")
+		}
+	default:
+		return l.T("settings.help"), nil
+	}
+
+	if err := bm.saveChatSetting(ctx, cs); err != nil {
+		return l.E(err), nil
+	}
+
+	return text, nil
+}
+
+// weightReactionKey returns reaction of "/settings weight" command: emoji, ⭐ for paid reaction or custom emoji
+// from message entities.
+func weightReactionKey(m *models.Message, reaction string) reactionKey {
+	if reaction == paidReactionLabel {
+		return reactionKey{Type: string(models.ReactionTypeTypePaid), Reaction: paidReaction}
+	}
+
+	for _, e := range m.Entities {
+		if e.Type == models.MessageEntityTypeCustomEmoji && e.CustomEmojiID != "" {
+			return reactionKey{Type: string(models.ReactionTypeTypeCustomEmoji), Reaction: e.CustomEmojiID}
+		}
+	}
+
+	return reactionKey{Type: string(models.ReactionTypeTypeEmoji), Reaction: reaction}
+}
+
+// setReactionWeight applies command like "/settings weight 👎 -1" and recalculates message scores of the chat.
+func (bm *BotManager) setReactionWeight(ctx context.Context, l localizer, chatID int64, m *models.Message, reaction, value string) (string, error) {
+	weight, err := strconv.ParseFloat(strings.Replace(value, ",", ".", 1), 64)
+	if err != nil || math.IsNaN(weight) || math.Abs(weight) > maxReactionWeight {
+		return l.T("settings.weight_invalid", maxReactionWeight), nil
+	}
+
+	key := weightReactionKey(m, reaction)
+	now := time.Now()
+	rw := &db.ReactionWeight{ChatID: chatID, ReactionType: key.Type, Reaction: key.Reaction, Weight: weight, CreatedAt: now, UpdatedAt: now}
+	if err = bm.cr.SaveReactionWeight(ctx, rw); err != nil {
+		return "", fmt.Errorf("save reaction weight: %w", err)
+	}
+	if err = bm.cr.UpdateMessageReactionScores(ctx, chatID, nil); err != nil {
+		return "", fmt.Errorf("update message scores: %w", err)
+	}
+
+	return l.T("settings.weight_saved", key.Label(), strconv.FormatFloat(weight, 'f', -1, 64)), nil
+}
+
+// SettingsCallbackHandler navigates settings menu and applies changes made by chat admins.
+func (bm *BotManager) SettingsCallbackHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	cq := update.CallbackQuery
+	if cq == nil || cq.Message.Message == nil {
+		return
+	}
+	answer := &bot.AnswerCallbackQueryParams{CallbackQueryID: cq.ID}
+	defer bm.answerCallback(ctx, b, answer)
+
+	msg := cq.Message.Message
+	isAdmin, err := bm.isChatAdminUser(ctx, b, msg.Chat.ID, cq.From.ID)
+	if err != nil {
+		bm.Errorf("check chat admin: %v", err)
+		return
+	} else if !isAdmin {
+		cs, err := bm.chatSetting(ctx, msg.Chat.ID)
+		if err != nil {
+			bm.Errorf("%v", err)
+			return
+		}
+
+		answer.Text, answer.ShowAlert = chatLocalizer(cs, &cq.From).T("settings.admin_only"), true
+		return
+	}
+
+	action, value, hasValue := strings.Cut(strings.TrimPrefix(cq.Data, patternSettings), ":")
+	if action == settingsClose {
+		if _, err = b.DeleteMessage(ctx, &bot.DeleteMessageParams{ChatID: msg.Chat.ID, MessageID: msg.ID}); err != nil {
+			bm.Errorf("%v", err)
+		}
+		return
+	}
+
+	text, kb, err := bm.settingsMenu(ctx, msg.Chat.ID, &cq.From, action, value, hasValue)
+	if err != nil {
+		bm.Errorf("settings action=%q value=%q err=%q", action, value, err)
+		return
+	}
+
+	_, err = b.EditMessageText(ctx, &bot.EditMessageTextParams{
+		ChatID:      msg.Chat.ID,
+		MessageID:   msg.ID,
+		Text:        text,
+		ReplyMarkup: kb,
+	})
+	if err != nil {
+		bm.Errorf("%v", err)
+		return
+	}
+}
+
+// settingsMenu applies settings action if value is set and returns menu to show in language of changed settings.
+func (bm *BotManager) settingsMenu(ctx context.Context, chatID int64, requester *models.User, action, value string, hasValue bool) (string, *models.InlineKeyboardMarkup, error) {
+	cs, err := bm.chatSetting(ctx, chatID)
+	if err != nil {
+		return "", nil, err
+	}
+
+	level := action
+	if hasValue {
+		if level, err = applySettingsAction(&cs, action, value); err != nil {
+			return "", nil, err
+		}
+		if err = bm.saveChatSetting(ctx, cs); err != nil {
+			return "", nil, err
+		}
+	}
+
+	var topics map[int]string
+	if level == settingsExcluded {
+		if topics, err = bm.cr.ForumTopicTitles(ctx, chatID, cs.ExcludedThreadIDs); err != nil {
+			return "", nil, fmt.Errorf("fetch forum topics: %w", err)
+		}
+	}
+
+	l := chatLocalizer(cs, requester)
+	text := settingsText(l, cs)
+	if level == settingsExcluded {
+		text += "\n\n" + l.T("settings.help")
+	}
+
+	return text, settingsKeyboard(l, cs, level, topics), nil
+}
@@ -0,0 +1,115 @@
+package botsrv
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"botsrv/pkg/db"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// statsTopReactions is count of the most used reactions in chat stats.
+const statsTopReactions = 5
+
+// statsTrend returns change of reactions count against the previous period like "▲ 15% vs previous period".
+func statsTrend(l localizer, count, prev int) string {
+	if prev == 0 {
+		return l.T("stats.trend_new")
+	}
+
+	change := (count - prev) * 100 / prev
+	arrow := "▲"
+	if change < 0 {
+		arrow, change = "▼", -change
+	}
+
+	return l.T("stats.trend", arrow, change)
+}
+
+// statsText builds chat reaction analytics for period: totals, reactions per message, the most used reactions,
+// the busiest day and the trend against the previous period of the same length.
+func (bm *BotManager) statsText(ctx context.Context, l localizer, cs db.ChatSetting, spec string) (string, error) {
+	loc := settingLocation(cs)
+	period, err := parsePeriod(spec, time.Now(), loc)
+	if err != nil {
+		return "", fmt.Errorf("incorrect period=%q: %w", spec, err)
+	}
+
+	res := "<b>" + escapeHTML(l.T("stats.header", period.title(l))) + "</b>\n\n"
+	stat, err := bm.cr.ChatReactionStat(ctx, cs.ChatID, period.From, period.To)
+	if err != nil {
+		return "", fmt.Errorf("fetch stats: %w", err)
+	} else if stat.ReactionsCount == 0 {
+		return res + l.T("no_reactions"), nil
+	}
+
+	lines := []string{l.T("stats.reactions", stat.ReactionsCount)}
+	if period.Windowed() {
+		prev, err := bm.cr.ChatReactionStat(ctx, cs.ChatID, period.From.Add(-period.To.Sub(period.From)), period.From)
+		if err != nil {
+			return "", fmt.Errorf("fetch previous stats: %w", err)
+		}
+		lines[0] += " (" + statsTrend(l, stat.ReactionsCount, prev.ReactionsCount) + ")"
+	}
+	lines = append(lines,
+		l.T("stats.messages", stat.MessagesCount),
+		l.T("stats.per_message", stat.AvgReactions, stat.MedianReactions),
+	)
+
+	top, err := bm.cr.ChatTopReactions(ctx, cs.ChatID, period.From, period.To, statsTopReactions)
+	if err != nil {
+		return "", fmt.Errorf("fetch top reactions: %w", err)
+	} else if len(top) > 0 {
+		lines = append(lines, l.T("stats.top", reactionBreakdown(top)))
+	}
+
+	day, err := bm.cr.ChatBusiestDay(ctx, cs.ChatID, period.From, period.To, loc)
+	if err != nil {
+		return "", fmt.Errorf("fetch busiest day: %w", err)
+	} else if day != nil {
+		lines = append(lines, l.T("stats.busiest_day", day.Day.Format(l.T("period.date")), l.N("reactions", day.ReactionsCount)))
+	}
+
+	return res + escapeHTML(strings.Join(lines, "\n")), nil
+}
+
+// StatsHandler sends chat reaction analytics for period from command arguments like "/stats week",
+// chat default period is used without arguments.
+func (bm *BotManager) StatsHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	m := update.Message
+	if m == nil || !isTrackedChat(m.Chat) {
+		return
+	}
+
+	cs, err := bm.chatSetting(ctx, m.Chat.ID)
+	if err != nil {
+		bm.Errorf("%v", err)
+		return
+	}
+	l := chatLocalizer(cs, m.From)
+
+	spec := cs.Period
+	if args := strings.Fields(m.Text)[1:]; len(args) > 0 {
+		spec = strings.ToLower(strings.Join(args, ""))
+	}
+
+	params := &bot.SendMessageParams{ChatID: m.Chat.ID, MessageThreadID: m.MessageThreadID}
+	if _, err = parsePeriod(spec, time.Now(), settingLocation(cs)); err != nil {
+		params.Text = l.E(err) + "\n\n" + l.T("period.help", statsCommand)
+	} else {
+		if params.Text, err = bm.statsText(ctx, l, cs, spec); err != nil {
+			bm.Errorf("Failed to build stats: %v", err)
+			return
+		}
+		params.ParseMode = models.ParseModeHTML
+	}
+
+	if _, err = b.SendMessage(ctx, params); err != nil {
+		bm.Errorf("%v", err)
+		return
+	}
+}
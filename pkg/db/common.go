@@ -3,6 +3,7 @@ package db
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/go-pg/pg/v10"
 	"github.com/go-pg/pg/v10/orm"
@@ -21,10 +22,30 @@ func NewCommonRepo(db orm.DB) CommonRepo {
 		db:      db,
 		filters: map[string][]Filter{},
 		sort: map[string][]SortField{
-			Tables.MessageReaction.Name: {{Column: Columns.MessageReaction.CreatedAt, Direction: SortDesc}},
+			Tables.MessageReaction.Name:      {{Column: Columns.MessageReaction.CreatedAt, Direction: SortDesc}},
+			Tables.MessageReactionCount.Name: {{Column: Columns.MessageReactionCount.Count, Direction: SortDesc}},
+			Tables.ReactionEvent.Name:        {{Column: Columns.ReactionEvent.CreatedAt, Direction: SortDesc}},
+			Tables.Message.Name:              {{Column: Columns.Message.SentAt, Direction: SortDesc}},
+			Tables.ForumTopic.Name:           {{Column: Columns.ForumTopic.Title, Direction: SortAsc}},
+			Tables.Chat.Name:                 {{Column: Columns.Chat.ID, Direction: SortAsc}},
+			Tables.ReactionWeight.Name:       {{Column: Columns.ReactionWeight.Weight, Direction: SortDesc}},
+			Tables.ReactionBucket.Name:       {{Column: Columns.ReactionBucket.BucketAt, Direction: SortDesc}},
+			Tables.DigestSchedule.Name:       {{Column: Columns.DigestSchedule.NextRunAt, Direction: SortAsc}},
+			Tables.ChatSetting.Name:          {{Column: Columns.ChatSetting.ChatID, Direction: SortAsc}},
+			Tables.User.Name:                 {{Column: Columns.User.ID, Direction: SortAsc}},
 		},
 		join: map[string][]string{
-			Tables.MessageReaction.Name: {TableColumns},
+			Tables.MessageReaction.Name:      {TableColumns, Columns.MessageReaction.Message},
+			Tables.MessageReactionCount.Name: {TableColumns},
+			Tables.ReactionEvent.Name:        {TableColumns},
+			Tables.Message.Name:              {TableColumns},
+			Tables.ForumTopic.Name:           {TableColumns},
+			Tables.Chat.Name:                 {TableColumns},
+			Tables.ReactionWeight.Name:       {TableColumns},
+			Tables.ReactionBucket.Name:       {TableColumns},
+			Tables.DigestSchedule.Name:       {TableColumns},
+			Tables.ChatSetting.Name:          {TableColumns},
+			Tables.User.Name:                 {TableColumns},
 		},
 	}
 }
@@ -128,3 +149,804 @@ func (cr CommonRepo) DeleteMessageReaction(ctx context.Context, messageID int, c
 
 	return res.RowsAffected() > 0, err
 }
+
+/*** MessageReactionCount ***/
+
+// FullMessageReactionCount returns full joins with all columns
+func (cr CommonRepo) FullMessageReactionCount() OpFunc {
+	return WithColumns(cr.join[Tables.MessageReactionCount.Name]...)
+}
+
+// DefaultMessageReactionCountSort returns default sort.
+func (cr CommonRepo) DefaultMessageReactionCountSort() OpFunc {
+	return WithSort(cr.sort[Tables.MessageReactionCount.Name]...)
+}
+
+// MessageReactionCountByID is a function that returns MessageReactionCount by ID(s) or nil.
+func (cr CommonRepo) MessageReactionCountByID(ctx context.Context, chatID int64, messageID int, reactionType string, reaction string, ops ...OpFunc) (*MessageReactionCount, error) {
+	return cr.OneMessageReactionCount(ctx, &MessageReactionCountSearch{ChatID: &chatID, MessageID: &messageID, ReactionType: &reactionType, Reaction: &reaction}, ops...)
+}
+
+// OneMessageReactionCount is a function that returns one MessageReactionCount by filters. It could return pg.ErrMultiRows.
+func (cr CommonRepo) OneMessageReactionCount(ctx context.Context, search *MessageReactionCountSearch, ops ...OpFunc) (*MessageReactionCount, error) {
+	obj := &MessageReactionCount{}
+	err := buildQuery(ctx, cr.db, obj, search, cr.filters[Tables.MessageReactionCount.Name], PagerTwo, ops...).Select()
+
+	if errors.Is(err, pg.ErrMultiRows) {
+		return nil, err
+	} else if errors.Is(err, pg.ErrNoRows) {
+		return nil, nil
+	}
+
+	return obj, err
+}
+
+// MessageReactionCountsByFilters returns MessageReactionCount list.
+func (cr CommonRepo) MessageReactionCountsByFilters(ctx context.Context, search *MessageReactionCountSearch, pager Pager, ops ...OpFunc) (messageReactionCounts []MessageReactionCount, err error) {
+	err = buildQuery(ctx, cr.db, &messageReactionCounts, search, cr.filters[Tables.MessageReactionCount.Name], pager, ops...).Select()
+	return
+}
+
+// CountMessageReactionCounts returns count
+func (cr CommonRepo) CountMessageReactionCounts(ctx context.Context, search *MessageReactionCountSearch, ops ...OpFunc) (int, error) {
+	return buildQuery(ctx, cr.db, &MessageReactionCount{}, search, cr.filters[Tables.MessageReactionCount.Name], PagerOne, ops...).Count()
+}
+
+// AddMessageReactionCount adds MessageReactionCount to DB.
+func (cr CommonRepo) AddMessageReactionCount(ctx context.Context, messageReactionCount *MessageReactionCount, ops ...OpFunc) (*MessageReactionCount, error) {
+	q := cr.db.ModelContext(ctx, messageReactionCount)
+	applyOps(q, ops...)
+	_, err := q.Insert()
+
+	return messageReactionCount, err
+}
+
+// UpdateMessageReactionCount updates MessageReactionCount in DB.
+func (cr CommonRepo) UpdateMessageReactionCount(ctx context.Context, messageReactionCount *MessageReactionCount, ops ...OpFunc) (bool, error) {
+	q := cr.db.ModelContext(ctx, messageReactionCount).WherePK()
+	if len(ops) == 0 {
+		q = q.ExcludeColumn(Columns.MessageReactionCount.ChatID, Columns.MessageReactionCount.MessageID, Columns.MessageReactionCount.ReactionType, Columns.MessageReactionCount.Reaction)
+	}
+	applyOps(q, ops...)
+	res, err := q.Update()
+	if err != nil {
+		return false, err
+	}
+
+	return res.RowsAffected() > 0, err
+}
+
+// DeleteMessageReactionCount deletes MessageReactionCount from DB.
+func (cr CommonRepo) DeleteMessageReactionCount(ctx context.Context, chatID int64, messageID int, reactionType string, reaction string) (deleted bool, err error) {
+	messageReactionCount := &MessageReactionCount{ChatID: chatID, MessageID: messageID, ReactionType: reactionType, Reaction: reaction}
+
+	res, err := cr.db.ModelContext(ctx, messageReactionCount).WherePK().Delete()
+	if err != nil {
+		return false, err
+	}
+
+	return res.RowsAffected() > 0, err
+}
+
+/*** ReactionEvent ***/
+
+// FullReactionEvent returns full joins with all columns
+func (cr CommonRepo) FullReactionEvent() OpFunc {
+	return WithColumns(cr.join[Tables.ReactionEvent.Name]...)
+}
+
+// DefaultReactionEventSort returns default sort.
+func (cr CommonRepo) DefaultReactionEventSort() OpFunc {
+	return WithSort(cr.sort[Tables.ReactionEvent.Name]...)
+}
+
+// ReactionEventByID is a function that returns ReactionEvent by ID(s) or nil.
+func (cr CommonRepo) ReactionEventByID(ctx context.Context, id int, ops ...OpFunc) (*ReactionEvent, error) {
+	return cr.OneReactionEvent(ctx, &ReactionEventSearch{ID: &id}, ops...)
+}
+
+// OneReactionEvent is a function that returns one ReactionEvent by filters. It could return pg.ErrMultiRows.
+func (cr CommonRepo) OneReactionEvent(ctx context.Context, search *ReactionEventSearch, ops ...OpFunc) (*ReactionEvent, error) {
+	obj := &ReactionEvent{}
+	err := buildQuery(ctx, cr.db, obj, search, cr.filters[Tables.ReactionEvent.Name], PagerTwo, ops...).Select()
+
+	if errors.Is(err, pg.ErrMultiRows) {
+		return nil, err
+	} else if errors.Is(err, pg.ErrNoRows) {
+		return nil, nil
+	}
+
+	return obj, err
+}
+
+// ReactionEventsByFilters returns ReactionEvent list.
+func (cr CommonRepo) ReactionEventsByFilters(ctx context.Context, search *ReactionEventSearch, pager Pager, ops ...OpFunc) (reactionEvents []ReactionEvent, err error) {
+	err = buildQuery(ctx, cr.db, &reactionEvents, search, cr.filters[Tables.ReactionEvent.Name], pager, ops...).Select()
+	return
+}
+
+// CountReactionEvents returns count
+func (cr CommonRepo) CountReactionEvents(ctx context.Context, search *ReactionEventSearch, ops ...OpFunc) (int, error) {
+	return buildQuery(ctx, cr.db, &ReactionEvent{}, search, cr.filters[Tables.ReactionEvent.Name], PagerOne, ops...).Count()
+}
+
+// AddReactionEvent adds ReactionEvent to DB.
+func (cr CommonRepo) AddReactionEvent(ctx context.Context, reactionEvent *ReactionEvent, ops ...OpFunc) (*ReactionEvent, error) {
+	q := cr.db.ModelContext(ctx, reactionEvent)
+	applyOps(q, ops...)
+	_, err := q.Insert()
+
+	return reactionEvent, err
+}
+
+// UpdateReactionEvent updates ReactionEvent in DB.
+func (cr CommonRepo) UpdateReactionEvent(ctx context.Context, reactionEvent *ReactionEvent, ops ...OpFunc) (bool, error) {
+	q := cr.db.ModelContext(ctx, reactionEvent).WherePK()
+	if len(ops) == 0 {
+		q = q.ExcludeColumn(Columns.ReactionEvent.ID, Columns.ReactionEvent.CreatedAt)
+	}
+	applyOps(q, ops...)
+	res, err := q.Update()
+	if err != nil {
+		return false, err
+	}
+
+	return res.RowsAffected() > 0, err
+}
+
+// DeleteReactionEvent deletes ReactionEvent from DB.
+func (cr CommonRepo) DeleteReactionEvent(ctx context.Context, id int) (deleted bool, err error) {
+	reactionEvent := &ReactionEvent{ID: id}
+
+	res, err := cr.db.ModelContext(ctx, reactionEvent).WherePK().Delete()
+	if err != nil {
+		return false, err
+	}
+
+	return res.RowsAffected() > 0, err
+}
+
+/*** Message ***/
+
+// FullMessage returns full joins with all columns
+func (cr CommonRepo) FullMessage() OpFunc {
+	return WithColumns(cr.join[Tables.Message.Name]...)
+}
+
+// DefaultMessageSort returns default sort.
+func (cr CommonRepo) DefaultMessageSort() OpFunc {
+	return WithSort(cr.sort[Tables.Message.Name]...)
+}
+
+// MessageByID is a function that returns Message by ID(s) or nil.
+func (cr CommonRepo) MessageByID(ctx context.Context, chatID int64, messageID int, ops ...OpFunc) (*Message, error) {
+	return cr.OneMessage(ctx, &MessageSearch{ChatID: &chatID, MessageID: &messageID}, ops...)
+}
+
+// OneMessage is a function that returns one Message by filters. It could return pg.ErrMultiRows.
+func (cr CommonRepo) OneMessage(ctx context.Context, search *MessageSearch, ops ...OpFunc) (*Message, error) {
+	obj := &Message{}
+	err := buildQuery(ctx, cr.db, obj, search, cr.filters[Tables.Message.Name], PagerTwo, ops...).Select()
+
+	if errors.Is(err, pg.ErrMultiRows) {
+		return nil, err
+	} else if errors.Is(err, pg.ErrNoRows) {
+		return nil, nil
+	}
+
+	return obj, err
+}
+
+// MessagesByFilters returns Message list.
+func (cr CommonRepo) MessagesByFilters(ctx context.Context, search *MessageSearch, pager Pager, ops ...OpFunc) (messages []Message, err error) {
+	err = buildQuery(ctx, cr.db, &messages, search, cr.filters[Tables.Message.Name], pager, ops...).Select()
+	return
+}
+
+// CountMessages returns count
+func (cr CommonRepo) CountMessages(ctx context.Context, search *MessageSearch, ops ...OpFunc) (int, error) {
+	return buildQuery(ctx, cr.db, &Message{}, search, cr.filters[Tables.Message.Name], PagerOne, ops...).Count()
+}
+
+// AddMessage adds Message to DB.
+func (cr CommonRepo) AddMessage(ctx context.Context, message *Message, ops ...OpFunc) (*Message, error) {
+	q := cr.db.ModelContext(ctx, message)
+	if len(ops) == 0 {
+		q = q.ExcludeColumn(Columns.Message.CreatedAt)
+	}
+	applyOps(q, ops...)
+	_, err := q.Insert()
+
+	return message, err
+}
+
+// UpdateMessage updates Message in DB.
+func (cr CommonRepo) UpdateMessage(ctx context.Context, message *Message, ops ...OpFunc) (bool, error) {
+	q := cr.db.ModelContext(ctx, message).WherePK()
+	if len(ops) == 0 {
+		q = q.ExcludeColumn(Columns.Message.ChatID, Columns.Message.MessageID, Columns.Message.CreatedAt)
+	}
+	applyOps(q, ops...)
+	res, err := q.Update()
+	if err != nil {
+		return false, err
+	}
+
+	return res.RowsAffected() > 0, err
+}
+
+// DeleteMessage deletes Message from DB.
+func (cr CommonRepo) DeleteMessage(ctx context.Context, chatID int64, messageID int) (deleted bool, err error) {
+	message := &Message{ChatID: chatID, MessageID: messageID}
+
+	res, err := cr.db.ModelContext(ctx, message).WherePK().Delete()
+	if err != nil {
+		return false, err
+	}
+
+	return res.RowsAffected() > 0, err
+}
+
+/*** ForumTopic ***/
+
+// FullForumTopic returns full joins with all columns
+func (cr CommonRepo) FullForumTopic() OpFunc {
+	return WithColumns(cr.join[Tables.ForumTopic.Name]...)
+}
+
+// DefaultForumTopicSort returns default sort.
+func (cr CommonRepo) DefaultForumTopicSort() OpFunc {
+	return WithSort(cr.sort[Tables.ForumTopic.Name]...)
+}
+
+// ForumTopicByID is a function that returns ForumTopic by ID(s) or nil.
+func (cr CommonRepo) ForumTopicByID(ctx context.Context, chatID int64, threadID int, ops ...OpFunc) (*ForumTopic, error) {
+	return cr.OneForumTopic(ctx, &ForumTopicSearch{ChatID: &chatID, ThreadID: &threadID}, ops...)
+}
+
+// OneForumTopic is a function that returns one ForumTopic by filters. It could return pg.ErrMultiRows.
+func (cr CommonRepo) OneForumTopic(ctx context.Context, search *ForumTopicSearch, ops ...OpFunc) (*ForumTopic, error) {
+	obj := &ForumTopic{}
+	err := buildQuery(ctx, cr.db, obj, search, cr.filters[Tables.ForumTopic.Name], PagerTwo, ops...).Select()
+
+	if errors.Is(err, pg.ErrMultiRows) {
+		return nil, err
+	} else if errors.Is(err, pg.ErrNoRows) {
+		return nil, nil
+	}
+
+	return obj, err
+}
+
+// ForumTopicsByFilters returns ForumTopic list.
+func (cr CommonRepo) ForumTopicsByFilters(ctx context.Context, search *ForumTopicSearch, pager Pager, ops ...OpFunc) (forumTopics []ForumTopic, err error) {
+	err = buildQuery(ctx, cr.db, &forumTopics, search, cr.filters[Tables.ForumTopic.Name], pager, ops...).Select()
+	return
+}
+
+// CountForumTopics returns count
+func (cr CommonRepo) CountForumTopics(ctx context.Context, search *ForumTopicSearch, ops ...OpFunc) (int, error) {
+	return buildQuery(ctx, cr.db, &ForumTopic{}, search, cr.filters[Tables.ForumTopic.Name], PagerOne, ops...).Count()
+}
+
+// AddForumTopic adds ForumTopic to DB.
+func (cr CommonRepo) AddForumTopic(ctx context.Context, forumTopic *ForumTopic, ops ...OpFunc) (*ForumTopic, error) {
+	q := cr.db.ModelContext(ctx, forumTopic)
+	if len(ops) == 0 {
+		q = q.ExcludeColumn(Columns.ForumTopic.CreatedAt)
+	}
+	applyOps(q, ops...)
+	_, err := q.Insert()
+
+	return forumTopic, err
+}
+
+// UpdateForumTopic updates ForumTopic in DB.
+func (cr CommonRepo) UpdateForumTopic(ctx context.Context, forumTopic *ForumTopic, ops ...OpFunc) (bool, error) {
+	q := cr.db.ModelContext(ctx, forumTopic).WherePK()
+	if len(ops) == 0 {
+		q = q.ExcludeColumn(Columns.ForumTopic.ChatID, Columns.ForumTopic.ThreadID, Columns.ForumTopic.CreatedAt)
+	}
+	applyOps(q, ops...)
+	res, err := q.Update()
+	if err != nil {
+		return false, err
+	}
+
+	return res.RowsAffected() > 0, err
+}
+
+// DeleteForumTopic deletes ForumTopic from DB.
+func (cr CommonRepo) DeleteForumTopic(ctx context.Context, chatID int64, threadID int) (deleted bool, err error) {
+	forumTopic := &ForumTopic{ChatID: chatID, ThreadID: threadID}
+
+	res, err := cr.db.ModelContext(ctx, forumTopic).WherePK().Delete()
+	if err != nil {
+		return false, err
+	}
+
+	return res.RowsAffected() > 0, err
+}
+
+/*** Chat ***/
+
+// FullChat returns full joins with all columns
+func (cr CommonRepo) FullChat() OpFunc {
+	return WithColumns(cr.join[Tables.Chat.Name]...)
+}
+
+// DefaultChatSort returns default sort.
+func (cr CommonRepo) DefaultChatSort() OpFunc {
+	return WithSort(cr.sort[Tables.Chat.Name]...)
+}
+
+// ChatByID is a function that returns Chat by ID(s) or nil.
+func (cr CommonRepo) ChatByID(ctx context.Context, id int64, ops ...OpFunc) (*Chat, error) {
+	return cr.OneChat(ctx, &ChatSearch{ID: &id}, ops...)
+}
+
+// OneChat is a function that returns one Chat by filters. It could return pg.ErrMultiRows.
+func (cr CommonRepo) OneChat(ctx context.Context, search *ChatSearch, ops ...OpFunc) (*Chat, error) {
+	obj := &Chat{}
+	err := buildQuery(ctx, cr.db, obj, search, cr.filters[Tables.Chat.Name], PagerTwo, ops...).Select()
+
+	if errors.Is(err, pg.ErrMultiRows) {
+		return nil, err
+	} else if errors.Is(err, pg.ErrNoRows) {
+		return nil, nil
+	}
+
+	return obj, err
+}
+
+// ChatsByFilters returns Chat list.
+func (cr CommonRepo) ChatsByFilters(ctx context.Context, search *ChatSearch, pager Pager, ops ...OpFunc) (chats []Chat, err error) {
+	err = buildQuery(ctx, cr.db, &chats, search, cr.filters[Tables.Chat.Name], pager, ops...).Select()
+	return
+}
+
+// CountChats returns count
+func (cr CommonRepo) CountChats(ctx context.Context, search *ChatSearch, ops ...OpFunc) (int, error) {
+	return buildQuery(ctx, cr.db, &Chat{}, search, cr.filters[Tables.Chat.Name], PagerOne, ops...).Count()
+}
+
+// AddChat adds Chat to DB.
+func (cr CommonRepo) AddChat(ctx context.Context, chat *Chat, ops ...OpFunc) (*Chat, error) {
+	q := cr.db.ModelContext(ctx, chat)
+	if len(ops) == 0 {
+		q = q.ExcludeColumn(Columns.Chat.CreatedAt)
+	}
+	applyOps(q, ops...)
+	_, err := q.Insert()
+
+	return chat, err
+}
+
+// UpdateChat updates Chat in DB.
+func (cr CommonRepo) UpdateChat(ctx context.Context, chat *Chat, ops ...OpFunc) (bool, error) {
+	q := cr.db.ModelContext(ctx, chat).WherePK()
+	if len(ops) == 0 {
+		q = q.ExcludeColumn(Columns.Chat.ID, Columns.Chat.CreatedAt)
+	}
+	applyOps(q, ops...)
+	res, err := q.Update()
+	if err != nil {
+		return false, err
+	}
+
+	return res.RowsAffected() > 0, err
+}
+
+// DeleteChat deletes Chat from DB.
+func (cr CommonRepo) DeleteChat(ctx context.Context, id int64) (deleted bool, err error) {
+	chat := &Chat{ID: id}
+
+	res, err := cr.db.ModelContext(ctx, chat).WherePK().Delete()
+	if err != nil {
+		return false, err
+	}
+
+	return res.RowsAffected() > 0, err
+}
+
+/*** ReactionWeight ***/
+
+// FullReactionWeight returns full joins with all columns
+func (cr CommonRepo) FullReactionWeight() OpFunc {
+	return WithColumns(cr.join[Tables.ReactionWeight.Name]...)
+}
+
+// DefaultReactionWeightSort returns default sort.
+func (cr CommonRepo) DefaultReactionWeightSort() OpFunc {
+	return WithSort(cr.sort[Tables.ReactionWeight.Name]...)
+}
+
+// ReactionWeightByID is a function that returns ReactionWeight by ID(s) or nil.
+func (cr CommonRepo) ReactionWeightByID(ctx context.Context, chatID int64, reactionType string, reaction string, ops ...OpFunc) (*ReactionWeight, error) {
+	return cr.OneReactionWeight(ctx, &ReactionWeightSearch{ChatID: &chatID, ReactionType: &reactionType, Reaction: &reaction}, ops...)
+}
+
+// OneReactionWeight is a function that returns one ReactionWeight by filters. It could return pg.ErrMultiRows.
+func (cr CommonRepo) OneReactionWeight(ctx context.Context, search *ReactionWeightSearch, ops ...OpFunc) (*ReactionWeight, error) {
+	obj := &ReactionWeight{}
+	err := buildQuery(ctx, cr.db, obj, search, cr.filters[Tables.ReactionWeight.Name], PagerTwo, ops...).Select()
+
+	if errors.Is(err, pg.ErrMultiRows) {
+		return nil, err
+	} else if errors.Is(err, pg.ErrNoRows) {
+		return nil, nil
+	}
+
+	return obj, err
+}
+
+// ReactionWeightsByFilters returns ReactionWeight list.
+func (cr CommonRepo) ReactionWeightsByFilters(ctx context.Context, search *ReactionWeightSearch, pager Pager, ops ...OpFunc) (reactionWeights []ReactionWeight, err error) {
+	err = buildQuery(ctx, cr.db, &reactionWeights, search, cr.filters[Tables.ReactionWeight.Name], pager, ops...).Select()
+	return
+}
+
+// CountReactionWeights returns count
+func (cr CommonRepo) CountReactionWeights(ctx context.Context, search *ReactionWeightSearch, ops ...OpFunc) (int, error) {
+	return buildQuery(ctx, cr.db, &ReactionWeight{}, search, cr.filters[Tables.ReactionWeight.Name], PagerOne, ops...).Count()
+}
+
+// AddReactionWeight adds ReactionWeight to DB.
+func (cr CommonRepo) AddReactionWeight(ctx context.Context, reactionWeight *ReactionWeight, ops ...OpFunc) (*ReactionWeight, error) {
+	q := cr.db.ModelContext(ctx, reactionWeight)
+	if len(ops) == 0 {
+		q = q.ExcludeColumn(Columns.ReactionWeight.CreatedAt)
+	}
+	applyOps(q, ops...)
+	_, err := q.Insert()
+
+	return reactionWeight, err
+}
+
+// UpdateReactionWeight updates ReactionWeight in DB.
+func (cr CommonRepo) UpdateReactionWeight(ctx context.Context, reactionWeight *ReactionWeight, ops ...OpFunc) (bool, error) {
+	q := cr.db.ModelContext(ctx, reactionWeight).WherePK()
+	if len(ops) == 0 {
+		q = q.ExcludeColumn(Columns.ReactionWeight.ChatID, Columns.ReactionWeight.ReactionType, Columns.ReactionWeight.Reaction, Columns.ReactionWeight.CreatedAt)
+	}
+	applyOps(q, ops...)
+	res, err := q.Update()
+	if err != nil {
+		return false, err
+	}
+
+	return res.RowsAffected() > 0, err
+}
+
+// DeleteReactionWeight deletes ReactionWeight from DB.
+func (cr CommonRepo) DeleteReactionWeight(ctx context.Context, chatID int64, reactionType string, reaction string) (deleted bool, err error) {
+	reactionWeight := &ReactionWeight{ChatID: chatID, ReactionType: reactionType, Reaction: reaction}
+
+	res, err := cr.db.ModelContext(ctx, reactionWeight).WherePK().Delete()
+	if err != nil {
+		return false, err
+	}
+
+	return res.RowsAffected() > 0, err
+}
+
+/*** ReactionBucket ***/
+
+// FullReactionBucket returns full joins with all columns
+func (cr CommonRepo) FullReactionBucket() OpFunc {
+	return WithColumns(cr.join[Tables.ReactionBucket.Name]...)
+}
+
+// DefaultReactionBucketSort returns default sort.
+func (cr CommonRepo) DefaultReactionBucketSort() OpFunc {
+	return WithSort(cr.sort[Tables.ReactionBucket.Name]...)
+}
+
+// ReactionBucketByID is a function that returns ReactionBucket by ID(s) or nil.
+func (cr CommonRepo) ReactionBucketByID(ctx context.Context, chatID int64, messageID int, bucketAt time.Time, ops ...OpFunc) (*ReactionBucket, error) {
+	return cr.OneReactionBucket(ctx, &ReactionBucketSearch{ChatID: &chatID, MessageID: &messageID, BucketAt: &bucketAt}, ops...)
+}
+
+// OneReactionBucket is a function that returns one ReactionBucket by filters. It could return pg.ErrMultiRows.
+func (cr CommonRepo) OneReactionBucket(ctx context.Context, search *ReactionBucketSearch, ops ...OpFunc) (*ReactionBucket, error) {
+	obj := &ReactionBucket{}
+	err := buildQuery(ctx, cr.db, obj, search, cr.filters[Tables.ReactionBucket.Name], PagerTwo, ops...).Select()
+
+	if errors.Is(err, pg.ErrMultiRows) {
+		return nil, err
+	} else if errors.Is(err, pg.ErrNoRows) {
+		return nil, nil
+	}
+
+	return obj, err
+}
+
+// ReactionBucketsByFilters returns ReactionBucket list.
+func (cr CommonRepo) ReactionBucketsByFilters(ctx context.Context, search *ReactionBucketSearch, pager Pager, ops ...OpFunc) (reactionBuckets []ReactionBucket, err error) {
+	err = buildQuery(ctx, cr.db, &reactionBuckets, search, cr.filters[Tables.ReactionBucket.Name], pager, ops...).Select()
+	return
+}
+
+// CountReactionBuckets returns count
+func (cr CommonRepo) CountReactionBuckets(ctx context.Context, search *ReactionBucketSearch, ops ...OpFunc) (int, error) {
+	return buildQuery(ctx, cr.db, &ReactionBucket{}, search, cr.filters[Tables.ReactionBucket.Name], PagerOne, ops...).Count()
+}
+
+// AddReactionBucket adds ReactionBucket to DB.
+func (cr CommonRepo) AddReactionBucket(ctx context.Context, reactionBucket *ReactionBucket, ops ...OpFunc) (*ReactionBucket, error) {
+	q := cr.db.ModelContext(ctx, reactionBucket)
+	applyOps(q, ops...)
+	_, err := q.Insert()
+
+	return reactionBucket, err
+}
+
+// UpdateReactionBucket updates ReactionBucket in DB.
+func (cr CommonRepo) UpdateReactionBucket(ctx context.Context, reactionBucket *ReactionBucket, ops ...OpFunc) (bool, error) {
+	q := cr.db.ModelContext(ctx, reactionBucket).WherePK()
+	if len(ops) == 0 {
+		q = q.ExcludeColumn(Columns.ReactionBucket.ChatID, Columns.ReactionBucket.MessageID, Columns.ReactionBucket.BucketAt)
+	}
+	applyOps(q, ops...)
+	res, err := q.Update()
+	if err != nil {
+		return false, err
+	}
+
+	return res.RowsAffected() > 0, err
+}
+
+// DeleteReactionBucket deletes ReactionBucket from DB.
+func (cr CommonRepo) DeleteReactionBucket(ctx context.Context, chatID int64, messageID int, bucketAt time.Time) (deleted bool, err error) {
+	reactionBucket := &ReactionBucket{ChatID: chatID, MessageID: messageID, BucketAt: bucketAt}
+
+	res, err := cr.db.ModelContext(ctx, reactionBucket).WherePK().Delete()
+	if err != nil {
+		return false, err
+	}
+
+	return res.RowsAffected() > 0, err
+}
+
+/*** DigestSchedule ***/
+
+// FullDigestSchedule returns full joins with all columns
+func (cr CommonRepo) FullDigestSchedule() OpFunc {
+	return WithColumns(cr.join[Tables.DigestSchedule.Name]...)
+}
+
+// DefaultDigestScheduleSort returns default sort.
+func (cr CommonRepo) DefaultDigestScheduleSort() OpFunc {
+	return WithSort(cr.sort[Tables.DigestSchedule.Name]...)
+}
+
+// DigestScheduleByID is a function that returns DigestSchedule by ID(s) or nil.
+func (cr CommonRepo) DigestScheduleByID(ctx context.Context, id int, ops ...OpFunc) (*DigestSchedule, error) {
+	return cr.OneDigestSchedule(ctx, &DigestScheduleSearch{ID: &id}, ops...)
+}
+
+// OneDigestSchedule is a function that returns one DigestSchedule by filters. It could return pg.ErrMultiRows.
+func (cr CommonRepo) OneDigestSchedule(ctx context.Context, search *DigestScheduleSearch, ops ...OpFunc) (*DigestSchedule, error) {
+	obj := &DigestSchedule{}
+	err := buildQuery(ctx, cr.db, obj, search, cr.filters[Tables.DigestSchedule.Name], PagerTwo, ops...).Select()
+
+	if errors.Is(err, pg.ErrMultiRows) {
+		return nil, err
+	} else if errors.Is(err, pg.ErrNoRows) {
+		return nil, nil
+	}
+
+	return obj, err
+}
+
+// DigestSchedulesByFilters returns DigestSchedule list.
+func (cr CommonRepo) DigestSchedulesByFilters(ctx context.Context, search *DigestScheduleSearch, pager Pager, ops ...OpFunc) (digestSchedules []DigestSchedule, err error) {
+	err = buildQuery(ctx, cr.db, &digestSchedules, search, cr.filters[Tables.DigestSchedule.Name], pager, ops...).Select()
+	return
+}
+
+// CountDigestSchedules returns count
+func (cr CommonRepo) CountDigestSchedules(ctx context.Context, search *DigestScheduleSearch, ops ...OpFunc) (int, error) {
+	return buildQuery(ctx, cr.db, &DigestSchedule{}, search, cr.filters[Tables.DigestSchedule.Name], PagerOne, ops...).Count()
+}
+
+// AddDigestSchedule adds DigestSchedule to DB.
+func (cr CommonRepo) AddDigestSchedule(ctx context.Context, digestSchedule *DigestSchedule, ops ...OpFunc) (*DigestSchedule, error) {
+	q := cr.db.ModelContext(ctx, digestSchedule)
+	if len(ops) == 0 {
+		q = q.ExcludeColumn(Columns.DigestSchedule.ID, Columns.DigestSchedule.CreatedAt)
+	}
+	applyOps(q, ops...)
+	_, err := q.Insert()
+
+	return digestSchedule, err
+}
+
+// UpdateDigestSchedule updates DigestSchedule in DB.
+func (cr CommonRepo) UpdateDigestSchedule(ctx context.Context, digestSchedule *DigestSchedule, ops ...OpFunc) (bool, error) {
+	q := cr.db.ModelContext(ctx, digestSchedule).WherePK()
+	if len(ops) == 0 {
+		q = q.ExcludeColumn(Columns.DigestSchedule.ID, Columns.DigestSchedule.ChatID, Columns.DigestSchedule.CreatedAt)
+	}
+	applyOps(q, ops...)
+	res, err := q.Update()
+	if err != nil {
+		return false, err
+	}
+
+	return res.RowsAffected() > 0, err
+}
+
+// DeleteDigestSchedule deletes DigestSchedule from DB.
+func (cr CommonRepo) DeleteDigestSchedule(ctx context.Context, id int) (deleted bool, err error) {
+	digestSchedule := &DigestSchedule{ID: id}
+
+	res, err := cr.db.ModelContext(ctx, digestSchedule).WherePK().Delete()
+	if err != nil {
+		return false, err
+	}
+
+	return res.RowsAffected() > 0, err
+}
+
+/*** ChatSetting ***/
+
+// FullChatSetting returns full joins with all columns
+func (cr CommonRepo) FullChatSetting() OpFunc {
+	return WithColumns(cr.join[Tables.ChatSetting.Name]...)
+}
+
+// DefaultChatSettingSort returns default sort.
+func (cr CommonRepo) DefaultChatSettingSort() OpFunc {
+	return WithSort(cr.sort[Tables.ChatSetting.Name]...)
+}
+
+// ChatSettingByID is a function that returns ChatSetting by ID(s) or nil.
+func (cr CommonRepo) ChatSettingByID(ctx context.Context, chatID int64, ops ...OpFunc) (*ChatSetting, error) {
+	return cr.OneChatSetting(ctx, &ChatSettingSearch{ChatID: &chatID}, ops...)
+}
+
+// OneChatSetting is a function that returns one ChatSetting by filters. It could return pg.ErrMultiRows.
+func (cr CommonRepo) OneChatSetting(ctx context.Context, search *ChatSettingSearch, ops ...OpFunc) (*ChatSetting, error) {
+	obj := &ChatSetting{}
+	err := buildQuery(ctx, cr.db, obj, search, cr.filters[Tables.ChatSetting.Name], PagerTwo, ops...).Select()
+
+	if errors.Is(err, pg.ErrMultiRows) {
+		return nil, err
+	} else if errors.Is(err, pg.ErrNoRows) {
+		return nil, nil
+	}
+
+	return obj, err
+}
+
+// ChatSettingsByFilters returns ChatSetting list.
+func (cr CommonRepo) ChatSettingsByFilters(ctx context.Context, search *ChatSettingSearch, pager Pager, ops ...OpFunc) (chatSettings []ChatSetting, err error) {
+	err = buildQuery(ctx, cr.db, &chatSettings, search, cr.filters[Tables.ChatSetting.Name], pager, ops...).Select()
+	return
+}
+
+// CountChatSettings returns count
+func (cr CommonRepo) CountChatSettings(ctx context.Context, search *ChatSettingSearch, ops ...OpFunc) (int, error) {
+	return buildQuery(ctx, cr.db, &ChatSetting{}, search, cr.filters[Tables.ChatSetting.Name], PagerOne, ops...).Count()
+}
+
+// AddChatSetting adds ChatSetting to DB.
+func (cr CommonRepo) AddChatSetting(ctx context.Context, chatSetting *ChatSetting, ops ...OpFunc) (*ChatSetting, error) {
+	q := cr.db.ModelContext(ctx, chatSetting)
+	if len(ops) == 0 {
+		q = q.ExcludeColumn(Columns.ChatSetting.CreatedAt)
+	}
+	applyOps(q, ops...)
+	_, err := q.Insert()
+
+	return chatSetting, err
+}
+
+// UpdateChatSetting updates ChatSetting in DB.
+func (cr CommonRepo) UpdateChatSetting(ctx context.Context, chatSetting *ChatSetting, ops ...OpFunc) (bool, error) {
+	q := cr.db.ModelContext(ctx, chatSetting).WherePK()
+	if len(ops) == 0 {
+		q = q.ExcludeColumn(Columns.ChatSetting.ChatID, Columns.ChatSetting.CreatedAt)
+	}
+	applyOps(q, ops...)
+	res, err := q.Update()
+	if err != nil {
+		return false, err
+	}
+
+	return res.RowsAffected() > 0, err
+}
+
+// DeleteChatSetting deletes ChatSetting from DB.
+func (cr CommonRepo) DeleteChatSetting(ctx context.Context, chatID int64) (deleted bool, err error) {
+	chatSetting := &ChatSetting{ChatID: chatID}
+
+	res, err := cr.db.ModelContext(ctx, chatSetting).WherePK().Delete()
+	if err != nil {
+		return false, err
+	}
+
+	return res.RowsAffected() > 0, err
+}
+
+/*** User ***/
+
+// FullUser returns full joins with all columns
+func (cr CommonRepo) FullUser() OpFunc {
+	return WithColumns(cr.join[Tables.User.Name]...)
+}
+
+// DefaultUserSort returns default sort.
+func (cr CommonRepo) DefaultUserSort() OpFunc {
+	return WithSort(cr.sort[Tables.User.Name]...)
+}
+
+// UserByID is a function that returns User by ID(s) or nil.
+func (cr CommonRepo) UserByID(ctx context.Context, id int64, ops ...OpFunc) (*User, error) {
+	return cr.OneUser(ctx, &UserSearch{ID: &id}, ops...)
+}
+
+// OneUser is a function that returns one User by filters. It could return pg.ErrMultiRows.
+func (cr CommonRepo) OneUser(ctx context.Context, search *UserSearch, ops ...OpFunc) (*User, error) {
+	obj := &User{}
+	err := buildQuery(ctx, cr.db, obj, search, cr.filters[Tables.User.Name], PagerTwo, ops...).Select()
+
+	if errors.Is(err, pg.ErrMultiRows) {
+		return nil, err
+	} else if errors.Is(err, pg.ErrNoRows) {
+		return nil, nil
+	}
+
+	return obj, err
+}
+
+// UsersByFilters returns User list.
+func (cr CommonRepo) UsersByFilters(ctx context.Context, search *UserSearch, pager Pager, ops ...OpFunc) (users []User, err error) {
+	err = buildQuery(ctx, cr.db, &users, search, cr.filters[Tables.User.Name], pager, ops...).Select()
+	return
+}
+
+// CountUsers returns count
+func (cr CommonRepo) CountUsers(ctx context.Context, search *UserSearch, ops ...OpFunc) (int, error) {
+	return buildQuery(ctx, cr.db, &User{}, search, cr.filters[Tables.User.Name], PagerOne, ops...).Count()
+}
+
+// AddUser adds User to DB.
+func (cr CommonRepo) AddUser(ctx context.Context, user *User, ops ...OpFunc) (*User, error) {
+	q := cr.db.ModelContext(ctx, user)
+	if len(ops) == 0 {
+		q = q.ExcludeColumn(Columns.User.CreatedAt)
+	}
+	applyOps(q, ops...)
+	_, err := q.Insert()
+
+	return user, err
+}
+
+// UpdateUser updates User in DB.
+func (cr CommonRepo) UpdateUser(ctx context.Context, user *User, ops ...OpFunc) (bool, error) {
+	q := cr.db.ModelContext(ctx, user).WherePK()
+	if len(ops) == 0 {
+		q = q.ExcludeColumn(Columns.User.ID, Columns.User.CreatedAt)
+	}
+	applyOps(q, ops...)
+	res, err := q.Update()
+	if err != nil {
+		return false, err
+	}
+
+	return res.RowsAffected() > 0, err
+}
+
+// DeleteUser deletes User from DB.
+func (cr CommonRepo) DeleteUser(ctx context.Context, id int64) (deleted bool, err error) {
+	user := &User{ID: id}
+
+	res, err := cr.db.ModelContext(ctx, user).WherePK().Delete()
+	if err != nil {
+		return false, err
+	}
+
+	return res.RowsAffected() > 0, err
+}
@@ -1 +1,809 @@
 package db
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/go-pg/pg/v10/orm"
+)
+
+// MessageReactionCountsByMessages returns positive reaction counts for given chat messages grouped by message ID.
+func (cr CommonRepo) MessageReactionCountsByMessages(ctx context.Context, chatID int64, messageIDs []int) (map[int][]MessageReactionCount, error) {
+	res := make(map[int][]MessageReactionCount, len(messageIDs))
+	if len(messageIDs) == 0 {
+		return res, nil
+	}
+
+	search := &MessageReactionCountSearch{ChatID: &chatID, MessageIDs: messageIDs}
+	search.With(`?.? > 0`, pg.Ident(Tables.MessageReactionCount.Alias), pg.Ident(Columns.MessageReactionCount.Count))
+
+	list, err := cr.MessageReactionCountsByFilters(ctx, search, PagerNoLimit, cr.DefaultMessageReactionCountSort())
+	if err != nil {
+		return nil, err
+	}
+
+	for _, mrc := range list {
+		res[mrc.MessageID] = append(res[mrc.MessageID], mrc)
+	}
+
+	return res, nil
+}
+
+// SetMessageReactionCounts replaces all reaction counters of the message with given ones.
+// It should be called inside transaction.
+func (cr CommonRepo) SetMessageReactionCounts(ctx context.Context, chatID int64, messageID int, counts []MessageReactionCount) error {
+	if _, err := cr.db.ModelContext(ctx, (*MessageReactionCount)(nil)).
+		Where(`?.? = ?`, pg.Ident(Tables.MessageReactionCount.Alias), pg.Ident(Columns.MessageReactionCount.ChatID), chatID).
+		Where(`?.? = ?`, pg.Ident(Tables.MessageReactionCount.Alias), pg.Ident(Columns.MessageReactionCount.MessageID), messageID).
+		Delete(); err != nil {
+		return err
+	}
+
+	if len(counts) == 0 {
+		return nil
+	}
+
+	_, err := cr.db.ModelContext(ctx, &counts).Insert()
+	return err
+}
+
+// messageRelationAlias is the table alias of joined MessageReaction.Message relation.
+const messageRelationAlias = "message"
+
+// SaveMessage adds message metadata to DB. Already stored messages are kept as is.
+func (cr CommonRepo) SaveMessage(ctx context.Context, message *Message) error {
+	_, err := cr.db.ModelContext(ctx, message).
+		ExcludeColumn(Columns.Message.CreatedAt).
+		OnConflict(`DO NOTHING`).
+		Insert()
+
+	return err
+}
+
+// IncrementMessageReaction atomically adds reactions and reactors deltas to message counters in one statement.
+// Message row is created on first call, counters never go below zero.
+func (cr CommonRepo) IncrementMessageReaction(ctx context.Context, chatID int64, messageID int, reactionsDelta, reactorsDelta int) error {
+	_, err := cr.db.ExecContext(ctx, `
+		INSERT INTO "messageReactions" AS t ("chatId", "messageId", "reactionsCount", "reactorsCount")
+		VALUES (?0, ?1, greatest(?2, 0), greatest(?3, 0))
+		ON CONFLICT ("chatId", "messageId") DO UPDATE SET
+			"reactionsCount" = greatest(t."reactionsCount" + ?2, 0),
+			"reactorsCount" = greatest(t."reactorsCount" + ?3, 0)`,
+		chatID, messageID, reactionsDelta, reactorsDelta)
+
+	return err
+}
+
+// IncrementMessageReactionCount atomically adds delta to reaction counter of the message in one statement.
+// Counter row is created on first call, counter never goes below zero.
+func (cr CommonRepo) IncrementMessageReactionCount(ctx context.Context, chatID int64, messageID int, reactionType, reaction string, delta int) error {
+	_, err := cr.db.ExecContext(ctx, `
+		INSERT INTO "messageReactionCounts" AS t ("chatId", "messageId", "reactionType", "reaction", "count", "updatedAt")
+		VALUES (?0, ?1, ?2, ?3, greatest(?4, 0), now())
+		ON CONFLICT ("chatId", "messageId", "reactionType", "reaction") DO UPDATE SET
+			"count" = greatest(t."count" + ?4, 0),
+			"updatedAt" = now()`,
+		chatID, messageID, reactionType, reaction, delta)
+
+	return err
+}
+
+// SyncMessageReaction atomically sets total reactions count of the message received at syncedAt.
+// Returns false if message already has counters synced by a newer update, otherwise returns total count change.
+func (cr CommonRepo) SyncMessageReaction(ctx context.Context, chatID int64, messageID int, reactionsCount int, syncedAt time.Time) (bool, int, error) {
+	var delta int
+	_, err := cr.db.QueryOneContext(ctx, pg.Scan(&delta), `
+		WITH prev AS (
+			SELECT "reactionsCount" FROM "messageReactions" WHERE "chatId" = ?0 AND "messageId" = ?1 FOR UPDATE
+		)
+		INSERT INTO "messageReactions" AS t ("chatId", "messageId", "reactionsCount", "countsSyncedAt")
+		VALUES (?0, ?1, ?2, ?3)
+		ON CONFLICT ("chatId", "messageId") DO UPDATE SET
+			"reactionsCount" = EXCLUDED."reactionsCount",
+			"countsSyncedAt" = EXCLUDED."countsSyncedAt"
+		WHERE t."countsSyncedAt" IS NULL OR t."countsSyncedAt" <= EXCLUDED."countsSyncedAt"
+		RETURNING t."reactionsCount" - coalesce((SELECT "reactionsCount" FROM prev), 0)`,
+		chatID, messageID, reactionsCount, syncedAt)
+	if errors.Is(err, pg.ErrNoRows) {
+		return false, 0, nil
+	} else if err != nil {
+		return false, 0, err
+	}
+
+	return true, delta, nil
+}
+
+// AddReactionEvents adds ReactionEvent list to DB in one statement.
+func (cr CommonRepo) AddReactionEvents(ctx context.Context, reactionEvents []ReactionEvent) error {
+	if len(reactionEvents) == 0 {
+		return nil
+	}
+
+	_, err := cr.db.ModelContext(ctx, &reactionEvents).Insert()
+	return err
+}
+
+// SaveForumTopic adds forum topic or updates its title.
+func (cr CommonRepo) SaveForumTopic(ctx context.Context, forumTopic *ForumTopic) error {
+	_, err := cr.db.ModelContext(ctx, forumTopic).
+		ExcludeColumn(Columns.ForumTopic.CreatedAt).
+		OnConflict(`("chatId", "threadId") DO UPDATE`).
+		Set(`"title" = EXCLUDED."title"`).
+		Set(`"updatedAt" = EXCLUDED."updatedAt"`).
+		Insert()
+
+	return err
+}
+
+// ForumTopicTitles returns topic titles of the chat by thread ID.
+func (cr CommonRepo) ForumTopicTitles(ctx context.Context, chatID int64, threadIDs []int) (map[int]string, error) {
+	res := make(map[int]string, len(threadIDs))
+	if len(threadIDs) == 0 {
+		return res, nil
+	}
+
+	list, err := cr.ForumTopicsByFilters(ctx, &ForumTopicSearch{ChatID: &chatID, ThreadIDs: threadIDs}, PagerNoLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, ft := range list {
+		res[ft.ThreadID] = ft.Title
+	}
+
+	return res, nil
+}
+
+// WithThread adds filter by forum topic of the message, nil threadID means General topic.
+// Messages without stored metadata are skipped. Query should be joined with Message relation.
+func (mrs *MessageReactionSearch) WithThread(threadID *int) *MessageReactionSearch {
+	if threadID != nil {
+		mrs.With(`?.? = ?`, pg.Ident(messageRelationAlias), pg.Ident(Columns.Message.ThreadID), *threadID)
+		return mrs
+	}
+
+	mrs.With(`?.? IS NOT NULL AND ?.? IS NULL`,
+		pg.Ident(messageRelationAlias), pg.Ident(Columns.Message.MessageID),
+		pg.Ident(messageRelationAlias), pg.Ident(Columns.Message.ThreadID),
+	)
+
+	return mrs
+}
+
+// chatTypeSupergroup is the type of chat basic groups are upgraded to.
+const chatTypeSupergroup = "supergroup"
+
+// chatDataTable is a table with collected chat data. Keys are primary key columns besides "chatId",
+// OwnKey is set for tables with own sequence key. MessageKeys are columns with message IDs of the chat.
+type chatDataTable struct {
+	Name        string
+	Keys        []string
+	OwnKey      bool
+	MessageKeys []string
+}
+
+// chatDataTables are tables with collected chat data, all of them have "chatId" column.
+var chatDataTables = []chatDataTable{
+	{Name: Tables.ReactionEvent.Name, OwnKey: true, MessageKeys: []string{Columns.ReactionEvent.MessageID}},
+	{Name: Tables.MessageReactionCount.Name, Keys: []string{Columns.MessageReactionCount.MessageID,
+		Columns.MessageReactionCount.ReactionType, Columns.MessageReactionCount.Reaction},
+		MessageKeys: []string{Columns.MessageReactionCount.MessageID}},
+	{Name: Tables.MessageReaction.Name, Keys: []string{Columns.MessageReaction.MessageID},
+		MessageKeys: []string{Columns.MessageReaction.MessageID}},
+	{Name: Tables.Message.Name, Keys: []string{Columns.Message.MessageID},
+		MessageKeys: []string{Columns.Message.MessageID, Columns.Message.ThreadID}},
+	{Name: Tables.ForumTopic.Name, Keys: []string{Columns.ForumTopic.ThreadID}},
+	{Name: Tables.ReactionBucket.Name, Keys: []string{Columns.ReactionBucket.MessageID, Columns.ReactionBucket.BucketAt},
+		MessageKeys: []string{Columns.ReactionBucket.MessageID}},
+	{Name: Tables.DigestSchedule.Name, OwnKey: true},
+	{Name: Tables.ReactionWeight.Name, Keys: []string{Columns.ReactionWeight.ReactionType, Columns.ReactionWeight.Reaction}},
+	{Name: Tables.ChatSetting.Name},
+}
+
+// SaveChatMember adds chat or updates its info and bot membership. Nil joinedAt keeps the previous join time.
+func (cr CommonRepo) SaveChatMember(ctx context.Context, chat *Chat) error {
+	_, err := cr.db.ModelContext(ctx, chat).
+		ExcludeColumn(Columns.Chat.CreatedAt).
+		OnConflict(`("chatId") DO UPDATE`).
+		Set(`"type" = EXCLUDED."type"`).
+		Set(`"title" = EXCLUDED."title"`).
+		Set(`"username" = EXCLUDED."username"`).
+		Set(`"isForum" = EXCLUDED."isForum"`).
+		Set(`"memberStatus" = EXCLUDED."memberStatus"`).
+		Set(`"adminRights" = EXCLUDED."adminRights"`).
+		Set(`"joinedAt" = coalesce(EXCLUDED."joinedAt", t."joinedAt")`).
+		Set(`"leftAt" = EXCLUDED."leftAt"`).
+		Set(`"cleanupAt" = EXCLUDED."cleanupAt"`).
+		Set(`"updatedAt" = EXCLUDED."updatedAt"`).
+		Insert()
+
+	return err
+}
+
+// EnsureChat adds chat to registry if it is not there yet, e.g. bot was added before registry existed.
+func (cr CommonRepo) EnsureChat(ctx context.Context, chat *Chat) error {
+	_, err := cr.db.ModelContext(ctx, chat).
+		ExcludeColumn(Columns.Chat.CreatedAt).
+		OnConflict(`DO NOTHING`).
+		Insert()
+
+	return err
+}
+
+// UpdateChatInfo updates type, title, username and forum flag of known chat.
+func (cr CommonRepo) UpdateChatInfo(ctx context.Context, chat *Chat) (bool, error) {
+	return cr.UpdateChat(ctx, chat, WithColumns(Columns.Chat.Type, Columns.Chat.Title, Columns.Chat.Username,
+		Columns.Chat.IsForum, Columns.Chat.UpdatedAt))
+}
+
+// DeleteChatData deletes collected data of the chat if its cleanup time has come and resets cleanup time.
+// Returns false if chat is not scheduled for cleanup anymore, e.g. bot was added back.
+func (cr CommonRepo) DeleteChatData(ctx context.Context, chatID int64, now time.Time) (bool, error) {
+	res, err := cr.db.ModelContext(ctx, &Chat{ID: chatID}).
+		Set(`"cleanupAt" = NULL`).
+		Set(`"updatedAt" = ?`, now).
+		WherePK().
+		Where(`"cleanupAt" <= ?`, now).
+		Update()
+	if err != nil {
+		return false, err
+	} else if res.RowsAffected() == 0 {
+		return false, nil
+	}
+
+	for _, table := range chatDataTables {
+		if _, err = cr.db.ExecContext(ctx, `DELETE FROM ? WHERE "chatId" = ?`, pg.Ident(table.Name), chatID); err != nil {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+// MigrateChat moves collected data and registry entry of basic group to the supergroup it was upgraded to.
+// Supergroup starts its own message IDs, so group message IDs are stored negated: they never collide with
+// supergroup ones and get no permalinks. Settings already present for the supergroup win, conflicting settings
+// of the group are dropped. Repeated calls do nothing.
+func (cr CommonRepo) MigrateChat(ctx context.Context, fromChatID, toChatID int64) error {
+	for _, table := range chatDataTables {
+		query := `UPDATE ? AS t SET "chatId" = ?`
+		args := []interface{}{pg.Ident(table.Name), toChatID}
+		for _, key := range table.MessageKeys {
+			query += `, ? = -abs(t.?)`
+			args = append(args, pg.Ident(key), pg.Ident(key))
+		}
+		query += ` WHERE t."chatId" = ?`
+		args = append(args, fromChatID)
+
+		if !table.OwnKey && len(table.MessageKeys) == 0 {
+			query += ` AND NOT EXISTS (SELECT 1 FROM ? AS n WHERE n."chatId" = ?`
+			args = append(args, pg.Ident(table.Name), toChatID)
+			for _, key := range table.Keys {
+				query += ` AND n.? = t.?`
+				args = append(args, pg.Ident(key), pg.Ident(key))
+			}
+			query += `)`
+		}
+
+		if _, err := cr.db.ExecContext(ctx, query, args...); err != nil {
+			return err
+		}
+		if _, err := cr.db.ExecContext(ctx, `DELETE FROM ? WHERE "chatId" = ?`, pg.Ident(table.Name), fromChatID); err != nil {
+			return err
+		}
+	}
+
+	_, err := cr.db.ExecContext(ctx, `INSERT INTO ? ("chatId", "type", "title", "username", "isForum", "memberStatus",
+			"adminRights", "joinedAt", "leftAt", "cleanupAt", "updatedAt")
+		SELECT ?, ?, "title", "username", "isForum", "memberStatus", "adminRights", "joinedAt", "leftAt", "cleanupAt", now()
+		FROM ? WHERE "chatId" = ?
+		ON CONFLICT ("chatId") DO NOTHING`,
+		pg.Ident(Tables.Chat.Name), toChatID, chatTypeSupergroup, pg.Ident(Tables.Chat.Name), fromChatID)
+	if err != nil {
+		return err
+	}
+
+	_, err = cr.DeleteChat(ctx, fromChatID)
+	return err
+}
+
+// SaveReactionWeight adds reaction weight of the chat or updates it. Message scores should be recalculated after.
+func (cr CommonRepo) SaveReactionWeight(ctx context.Context, reactionWeight *ReactionWeight) error {
+	_, err := cr.db.ModelContext(ctx, reactionWeight).
+		ExcludeColumn(Columns.ReactionWeight.CreatedAt).
+		OnConflict(`("chatId", "reactionType", "reaction") DO UPDATE`).
+		Set(`"weight" = EXCLUDED."weight"`).
+		Set(`"updatedAt" = EXCLUDED."updatedAt"`).
+		Insert()
+
+	return err
+}
+
+// UpdateMessageReactionScores recalculates score of chat messages as sum of reaction counts multiplied by
+// chat reaction weights, reactions without weight count as 1. Paid reaction count is the amount of stars only
+// after anonymous count update of the message: user updates don't have the amount, so each one counts as 1.
+// Empty messageIDs means all messages of the chat.
+func (cr CommonRepo) UpdateMessageReactionScores(ctx context.Context, chatID int64, messageIDs []int) error {
+	query := `
+		UPDATE "messageReactions" AS t SET "score" = coalesce((
+			SELECT sum(c."count" * coalesce(w."weight", 1))
+			FROM "messageReactionCounts" c
+			LEFT JOIN "reactionWeights" w ON w."chatId" = c."chatId"
+				AND w."reactionType" = c."reactionType" AND w."reaction" = c."reaction"
+			WHERE c."chatId" = t."chatId" AND c."messageId" = t."messageId"
+		), 0)
+		WHERE t."chatId" = ?0`
+	if len(messageIDs) > 0 {
+		query += ` AND t."messageId" IN (?1)`
+	}
+
+	_, err := cr.db.ExecContext(ctx, query, chatID, pg.In(messageIDs))
+	return err
+}
+
+// hotHalfLives is the number of half-lives after which reaction no longer affects hot ranking.
+const hotHalfLives = 10
+
+// WithReactedFrom adds filter by messages that got user reactions since from.
+func (mrs *MessageReactionSearch) WithReactedFrom(from time.Time) *MessageReactionSearch {
+	mrs.With(`EXISTS (SELECT 1 FROM ? e WHERE e.? = ?.? AND e.? = ?.? AND e.? >= ?)`,
+		pg.Ident(Tables.ReactionEvent.Name),
+		pg.Ident(Columns.ReactionEvent.ChatID), pg.Ident(Tables.MessageReaction.Alias), pg.Ident(Columns.MessageReaction.ChatID),
+		pg.Ident(Columns.ReactionEvent.MessageID), pg.Ident(Tables.MessageReaction.Alias), pg.Ident(Columns.MessageReaction.MessageID),
+		pg.Ident(Columns.ReactionEvent.CreatedAt), from,
+	)
+
+	return mrs
+}
+
+// HotRankingFrom returns the oldest reaction time that still affects hot ranking at now.
+func HotRankingFrom(halfLife time.Duration, now time.Time) time.Time {
+	return now.Add(-hotHalfLives * halfLife)
+}
+
+// WithHotSort sorts messages by hot rank: every added reaction weighs 1 halving each halfLife of its age,
+// removed reaction subtracts the same way. Rank is based on user reaction events only.
+func WithHotSort(halfLife time.Duration, now time.Time) OpFunc {
+	return func(query *orm.Query) {
+		query.OrderExpr(`(
+			SELECT greatest(coalesce(sum(
+				CASE WHEN e."isAdded" THEN 1 ELSE -1 END * power(0.5, extract(epoch FROM ?0 - e."createdAt") / ?1)
+			), 0), 0)
+			FROM "reactionEvents" e
+			WHERE e."chatId" = ?3."chatId" AND e."messageId" = ?3."messageId" AND e."createdAt" >= ?2
+		) DESC`, now, halfLife.Seconds(), HotRankingFrom(halfLife, now), pg.Ident(Tables.MessageReaction.Alias))
+	}
+}
+
+// IncrementReactionBucket atomically adds delta to reactions count of the message received in the UTC hour of bucketAt.
+func (cr CommonRepo) IncrementReactionBucket(ctx context.Context, chatID int64, messageID int, bucketAt time.Time, delta int) error {
+	_, err := cr.db.ExecContext(ctx, `
+		INSERT INTO "reactionBuckets" AS t ("chatId", "messageId", "bucketAt", "reactionsCount")
+		VALUES (?0, ?1, ?2, ?3)
+		ON CONFLICT ("chatId", "messageId", "bucketAt") DO UPDATE SET
+			"reactionsCount" = t."reactionsCount" + ?3`,
+		chatID, messageID, ReactionBucketAt(bucketAt), delta)
+
+	return err
+}
+
+// ReactionBucketAt returns start of hourly reactions bucket containing t. Bucket resolution is the UTC hour, so in
+// zones with half-hour offsets like Asia/Kolkata local hours and days are approximated: a bucket is counted in the
+// local hour or day it starts in.
+func ReactionBucketAt(t time.Time) time.Time {
+	return t.Truncate(time.Hour)
+}
+
+// periodReactionsExpr is reactions count of t message received in reaction buckets from ?0 till ?1.
+const periodReactionsExpr = `(
+	SELECT coalesce(sum(b."reactionsCount"), 0) FROM "reactionBuckets" b
+	WHERE b."chatId" = t."chatId" AND b."messageId" = t."messageId" AND b."bucketAt" >= ?0 AND b."bucketAt" < ?1
+)`
+
+// WithReactedInPeriod adds filter by messages that received more reactions than were removed in [from, to).
+// Period is rounded to hourly buckets: it starts with the bucket containing from and ends with the bucket containing to.
+func (mrs *MessageReactionSearch) WithReactedInPeriod(from, to time.Time) *MessageReactionSearch {
+	mrs.With(periodReactionsExpr+` > 0`, ReactionBucketAt(from), to)
+	return mrs
+}
+
+// WithPeriodReactionsSort sorts messages by reactions received in [from, to).
+func WithPeriodReactionsSort(from, to time.Time) OpFunc {
+	return func(query *orm.Query) {
+		query.OrderExpr(periodReactionsExpr+` DESC`, ReactionBucketAt(from), to)
+	}
+}
+
+// PeriodReactionCounts returns reactions count of chat messages received in [from, to) by message ID.
+func (cr CommonRepo) PeriodReactionCounts(ctx context.Context, chatID int64, messageIDs []int, from, to time.Time) (map[int]int, error) {
+	res := make(map[int]int, len(messageIDs))
+	if len(messageIDs) == 0 {
+		return res, nil
+	}
+
+	var list []struct {
+		MessageID      int
+		ReactionsCount int
+	}
+	_, err := cr.db.QueryContext(ctx, &list, `
+		SELECT "messageId" AS message_id, sum("reactionsCount") AS reactions_count
+		FROM "reactionBuckets"
+		WHERE "chatId" = ? AND "messageId" IN (?) AND "bucketAt" >= ? AND "bucketAt" < ?
+		GROUP BY "messageId"`,
+		chatID, pg.In(messageIDs), ReactionBucketAt(from), to)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, c := range list {
+		res[c.MessageID] = c.ReactionsCount
+	}
+
+	return res, nil
+}
+
+// SaveChatSetting adds chat settings or updates them.
+func (cr CommonRepo) SaveChatSetting(ctx context.Context, chatSetting *ChatSetting) error {
+	_, err := cr.db.ModelContext(ctx, chatSetting).
+		ExcludeColumn(Columns.ChatSetting.CreatedAt).
+		OnConflict(`("chatId") DO UPDATE`).
+		Set(`"topN" = EXCLUDED."topN"`).
+		Set(`"period" = EXCLUDED."period"`).
+		Set(`"sort" = EXCLUDED."sort"`).
+		Set(`"language" = EXCLUDED."language"`).
+		Set(`"timezone" = EXCLUDED."timezone"`).
+		Set(`"excludedUserIds" = EXCLUDED."excludedUserIds"`).
+		Set(`"excludedThreadIds" = EXCLUDED."excludedThreadIds"`).
+		Set(`"excludeBots" = EXCLUDED."excludeBots"`).
+		Set(`"excludeAnonymous" = EXCLUDED."excludeAnonymous"`).
+		Set(`"updatedAt" = EXCLUDED."updatedAt"`).
+		Insert()
+
+	return err
+}
+
+// WithExcludedUsers adds filter excluding messages of given users. Query should be joined with Message relation.
+func (mrs *MessageReactionSearch) WithExcludedUsers(userIDs []int64) *MessageReactionSearch {
+	if len(userIDs) == 0 {
+		return mrs
+	}
+
+	mrs.With(`(?0.?1 IS NULL OR ?0.?1 NOT IN (?2))`, pg.Ident(messageRelationAlias), pg.Ident(Columns.Message.UserID), pg.In(userIDs))
+	return mrs
+}
+
+// WithExcludedThreads adds filter excluding messages of given forum topics. Query should be joined with Message relation.
+func (mrs *MessageReactionSearch) WithExcludedThreads(threadIDs []int) *MessageReactionSearch {
+	if len(threadIDs) == 0 {
+		return mrs
+	}
+
+	mrs.With(`(?0.?1 IS NULL OR ?0.?1 NOT IN (?2))`, pg.Ident(messageRelationAlias), pg.Ident(Columns.Message.ThreadID), pg.In(threadIDs))
+	return mrs
+}
+
+// WithExcludedBots adds filter excluding messages of bots. Query should be joined with Message relation.
+func (mrs *MessageReactionSearch) WithExcludedBots() *MessageReactionSearch {
+	mrs.With(`?.? IS NOT TRUE`, pg.Ident(messageRelationAlias), pg.Ident(Columns.Message.IsBot))
+	return mrs
+}
+
+// WithExcludedAnonymous adds filter excluding messages of anonymous admins sent on behalf of the chat itself.
+// Query should be joined with Message relation.
+func (mrs *MessageReactionSearch) WithExcludedAnonymous() *MessageReactionSearch {
+	mrs.With(`?.? IS DISTINCT FROM ?.?`, pg.Ident(messageRelationAlias), pg.Ident(Columns.Message.SenderChatID),
+		pg.Ident(Tables.MessageReaction.Alias), pg.Ident(Columns.MessageReaction.ChatID))
+	return mrs
+}
+
+// authorIDExpr is author of joined message: sender chat for anonymous admins and channels, user otherwise.
+// User IDs are positive and chat IDs are negative, so they never collide.
+const authorIDExpr = `coalesce("` + messageRelationAlias + `"."senderChatId", "` + messageRelationAlias + `"."userId")`
+
+// AuthorStat is reactions received by messages of one author.
+type AuthorStat struct {
+	AuthorID       int64
+	AuthorName     *string
+	ReactionsCount int
+	MessagesCount  int
+}
+
+// AuthorStatsByFilters returns authors of messages matching search ranked by received reactions. If from is set,
+// reactions received in [from, to) are counted, search should be limited by the same period.
+// Messages without stored author are skipped.
+func (cr CommonRepo) AuthorStatsByFilters(ctx context.Context, search *MessageReactionSearch, pager Pager, from, to time.Time) ([]AuthorStat, error) {
+	var list []AuthorStat
+	q := buildQuery(ctx, cr.db, (*MessageReaction)(nil), search, cr.filters[Tables.MessageReaction.Name], pager).
+		Relation(Columns.MessageReaction.Message+"._").
+		ColumnExpr(authorIDExpr+` AS author_id`).
+		ColumnExpr(`(array_agg(?0.?1 ORDER BY ?0.?2 DESC) FILTER (WHERE ?0.?1 IS NOT NULL))[1] AS author_name`,
+			pg.Ident(messageRelationAlias), pg.Ident(Columns.Message.AuthorName), pg.Ident(Columns.Message.SentAt)).
+		ColumnExpr(`count(*) AS messages_count`)
+	if from.IsZero() {
+		q.ColumnExpr(`coalesce(sum(?.?), 0) AS reactions_count`, pg.Ident(Tables.MessageReaction.Alias), pg.Ident(Columns.MessageReaction.ReactionsCount))
+	} else {
+		q.ColumnExpr(`sum(`+periodReactionsExpr+`) AS reactions_count`, ReactionBucketAt(from), to)
+	}
+
+	err := q.Where(authorIDExpr + ` IS NOT NULL`).
+		GroupExpr(authorIDExpr).
+		OrderExpr(`reactions_count DESC, messages_count DESC, author_id`).
+		Select(&list)
+
+	return list, err
+}
+
+// CountAuthorStats returns count of authors of messages matching search.
+func (cr CommonRepo) CountAuthorStats(ctx context.Context, search *MessageReactionSearch) (int, error) {
+	var count int
+	err := buildQuery(ctx, cr.db, (*MessageReaction)(nil), search, cr.filters[Tables.MessageReaction.Name], PagerNoLimit).
+		Relation(Columns.MessageReaction.Message + "._").
+		ColumnExpr(`count(DISTINCT ` + authorIDExpr + `)`).
+		Select(pg.Scan(&count))
+
+	return count, err
+}
+
+// AuthorReactorCounts returns count of unique users and chats reacted in [from, to) to chat messages of given authors
+// by author ID.
+func (cr CommonRepo) AuthorReactorCounts(ctx context.Context, chatID int64, authorIDs []int64, from, to time.Time) (map[int64]int, error) {
+	res := make(map[int64]int, len(authorIDs))
+	if len(authorIDs) == 0 {
+		return res, nil
+	}
+
+	var list []struct {
+		AuthorID      int64
+		ReactorsCount int
+	}
+	_, err := cr.db.QueryContext(ctx, &list, `
+		SELECT coalesce(m."senderChatId", m."userId") AS author_id,
+			count(DISTINCT coalesce(e."userId", e."actorChatId")) AS reactors_count
+		FROM "reactionEvents" e
+		JOIN "messages" m ON m."chatId" = e."chatId" AND m."messageId" = e."messageId"
+		WHERE e."chatId" = ? AND e."isAdded" AND coalesce(m."senderChatId", m."userId") IN (?)
+			AND e."createdAt" >= ? AND e."createdAt" < ?
+		GROUP BY 1`,
+		chatID, pg.In(authorIDs), from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, c := range list {
+		res[c.AuthorID] = c.ReactorsCount
+	}
+
+	return res, nil
+}
+
+// SaveUsers adds users or updates their names in one statement. Opt-out is kept.
+func (cr CommonRepo) SaveUsers(ctx context.Context, users []User) error {
+	if len(users) == 0 {
+		return nil
+	}
+
+	_, err := cr.db.ModelContext(ctx, &users).
+		ExcludeColumn(Columns.User.CreatedAt, Columns.User.OptedOutAt).
+		OnConflict(`("userId") DO UPDATE`).
+		Set(`"name" = EXCLUDED."name"`).
+		Set(`"username" = EXCLUDED."username"`).
+		Set(`"updatedAt" = EXCLUDED."updatedAt"`).
+		Insert()
+
+	return err
+}
+
+// SetUserOptOut adds user or updates it with opt-out time, nil opts user back in.
+func (cr CommonRepo) SetUserOptOut(ctx context.Context, user *User) error {
+	_, err := cr.db.ModelContext(ctx, user).
+		ExcludeColumn(Columns.User.CreatedAt).
+		OnConflict(`("userId") DO UPDATE`).
+		Set(`"name" = EXCLUDED."name"`).
+		Set(`"username" = EXCLUDED."username"`).
+		Set(`"optedOutAt" = EXCLUDED."optedOutAt"`).
+		Set(`"updatedAt" = EXCLUDED."updatedAt"`).
+		Insert()
+
+	return err
+}
+
+// WithCreatedTo adds filter of events created before to.
+func (res *ReactionEventSearch) WithCreatedTo(to time.Time) *ReactionEventSearch {
+	res.With(`?.? < ?`, pg.Ident(Tables.ReactionEvent.Alias), pg.Ident(Columns.ReactionEvent.CreatedAt), to)
+	return res
+}
+
+// WithExcludedReactors adds filter excluding reactions of given users.
+func (res *ReactionEventSearch) WithExcludedReactors(userIDs []int64) *ReactionEventSearch {
+	if len(userIDs) == 0 {
+		return res
+	}
+
+	res.With(`?.? NOT IN (?)`, pg.Ident(Tables.ReactionEvent.Alias), pg.Ident(Columns.ReactionEvent.UserID), pg.In(userIDs))
+	return res
+}
+
+// ReactorStat is reactions given by one user.
+type ReactorStat struct {
+	UserID         int64
+	UserName       *string
+	ReactionsCount int
+	MessagesCount  int
+	// FavoriteReactionType and FavoriteReaction are the most frequently added reaction.
+	FavoriteReactionType string
+	FavoriteReaction     string
+}
+
+// favoriteReactionExpr is the most frequently added reaction as array of type and reaction.
+const favoriteReactionExpr = `mode() WITHIN GROUP (ORDER BY ARRAY["t"."reactionType", "t"."reaction"]) FILTER (WHERE "t"."isAdded")`
+
+// ReactorStatsByFilters returns users reacted in events matching search ranked by count of reactions they left,
+// removed reactions are subtracted. Reactions of channels and anonymous admins and users opted out are skipped.
+func (cr CommonRepo) ReactorStatsByFilters(ctx context.Context, search *ReactionEventSearch, pager Pager) ([]ReactorStat, error) {
+	var list []ReactorStat
+	err := buildQuery(ctx, cr.db, (*ReactionEvent)(nil), search, cr.filters[Tables.ReactionEvent.Name], pager).
+		Join(`LEFT JOIN ?0 AS "u" ON "u".?1 = ?2.?3`,
+			pg.Ident(Tables.User.Name), pg.Ident(Columns.User.ID), pg.Ident(Tables.ReactionEvent.Alias), pg.Ident(Columns.ReactionEvent.UserID)).
+		ColumnExpr(`?.? AS user_id`, pg.Ident(Tables.ReactionEvent.Alias), pg.Ident(Columns.ReactionEvent.UserID)).
+		ColumnExpr(`"u".? AS user_name`, pg.Ident(Columns.User.Name)).
+		ColumnExpr(`sum(CASE WHEN ?.? THEN 1 ELSE -1 END) AS reactions_count`, pg.Ident(Tables.ReactionEvent.Alias), pg.Ident(Columns.ReactionEvent.IsAdded)).
+		ColumnExpr(`count(DISTINCT ?0.?1) FILTER (WHERE ?0.?2) AS messages_count`,
+			pg.Ident(Tables.ReactionEvent.Alias), pg.Ident(Columns.ReactionEvent.MessageID), pg.Ident(Columns.ReactionEvent.IsAdded)).
+		ColumnExpr(`(`+favoriteReactionExpr+`)[1] AS favorite_reaction_type`).
+		ColumnExpr(`(`+favoriteReactionExpr+`)[2] AS favorite_reaction`).
+		Where(`?.? IS NOT NULL`, pg.Ident(Tables.ReactionEvent.Alias), pg.Ident(Columns.ReactionEvent.UserID)).
+		Where(`"u".? IS NULL`, pg.Ident(Columns.User.OptedOutAt)).
+		GroupExpr(`?.?, "u".?`, pg.Ident(Tables.ReactionEvent.Alias), pg.Ident(Columns.ReactionEvent.UserID), pg.Ident(Columns.User.Name)).
+		Having(`sum(CASE WHEN ?.? THEN 1 ELSE -1 END) > 0`, pg.Ident(Tables.ReactionEvent.Alias), pg.Ident(Columns.ReactionEvent.IsAdded)).
+		OrderExpr(`reactions_count DESC, messages_count DESC, user_id`).
+		Select(&list)
+
+	return list, err
+}
+
+// ChatStat is reactions received by chat messages.
+type ChatStat struct {
+	ReactionsCount  int
+	MessagesCount   int
+	AvgReactions    float64
+	MedianReactions float64
+}
+
+// ChatReactionStat returns reactions received by chat messages in [from, to), only messages with reactions are
+// counted. Period is rounded to hourly buckets. Zero from counts current totals of messages.
+func (cr CommonRepo) ChatReactionStat(ctx context.Context, chatID int64, from, to time.Time) (ChatStat, error) {
+	perMessage := `SELECT "reactionsCount" AS n FROM "messageReactions" WHERE "chatId" = ?0 AND "reactionsCount" > 0`
+	if !from.IsZero() {
+		perMessage = `SELECT sum("reactionsCount") AS n FROM "reactionBuckets"
+			WHERE "chatId" = ?0 AND "bucketAt" >= ?1 AND "bucketAt" < ?2
+			GROUP BY "messageId" HAVING sum("reactionsCount") > 0`
+	}
+
+	var stat ChatStat
+	_, err := cr.db.QueryOneContext(ctx, &stat, `
+		SELECT coalesce(sum(n), 0) AS reactions_count, count(*) AS messages_count,
+			coalesce(avg(n), 0) AS avg_reactions,
+			coalesce(percentile_cont(0.5) WITHIN GROUP (ORDER BY n), 0) AS median_reactions
+		FROM (`+perMessage+`) s`,
+		chatID, ReactionBucketAt(from), to)
+
+	return stat, err
+}
+
+// ChatTopReactions returns the most used reactions of chat messages. Reactions left in [from, to) are counted by
+// reaction events, so anonymous reactions are skipped. Zero from counts current totals of messages.
+func (cr CommonRepo) ChatTopReactions(ctx context.Context, chatID int64, from, to time.Time, limit int) ([]MessageReactionCount, error) {
+	query := `
+		SELECT "reactionType", "reaction", sum("count") AS "count" FROM "messageReactionCounts"
+		WHERE "chatId" = ?0 AND "count" > 0
+		GROUP BY 1, 2 ORDER BY 3 DESC, 2 LIMIT ?3`
+	if !from.IsZero() {
+		query = `
+			SELECT "reactionType", "reaction", sum(CASE WHEN "isAdded" THEN 1 ELSE -1 END) AS "count" FROM "reactionEvents"
+			WHERE "chatId" = ?0 AND "createdAt" >= ?1 AND "createdAt" < ?2
+			GROUP BY 1, 2 HAVING sum(CASE WHEN "isAdded" THEN 1 ELSE -1 END) > 0 ORDER BY 3 DESC, 2 LIMIT ?3`
+	}
+
+	var list []MessageReactionCount
+	_, err := cr.db.QueryContext(ctx, &list, query, chatID, from, to, limit)
+
+	return list, err
+}
+
+// DayReactions is reactions received by chat messages during a day.
+type DayReactions struct {
+	Day            time.Time
+	ReactionsCount int
+}
+
+// ChatBusiestDay returns day of [from, to) in loc when chat messages received the most reactions, nil if there were
+// no reactions. Zero from searches all time.
+func (cr CommonRepo) ChatBusiestDay(ctx context.Context, chatID int64, from, to time.Time, loc *time.Location) (*DayReactions, error) {
+	var list []DayReactions
+	_, err := cr.db.QueryContext(ctx, &list, `
+		SELECT date_trunc('day', "bucketAt" AT TIME ZONE ?3) AS day, sum("reactionsCount") AS reactions_count
+		FROM "reactionBuckets"
+		WHERE "chatId" = ?0 AND "bucketAt" >= ?1 AND "bucketAt" < ?2
+		GROUP BY 1 HAVING sum("reactionsCount") > 0
+		ORDER BY 2 DESC, 1 DESC LIMIT 1`,
+		chatID, ReactionBucketAt(from), to, loc.String())
+	if err != nil || len(list) == 0 {
+		return nil, err
+	}
+
+	// day is local date without zone
+	day := list[0].Day
+	list[0].Day = time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, loc)
+
+	return &list[0], nil
+}
+
+// ReactionPoint is reactions received by chat messages during an hour or a day.
+type ReactionPoint struct {
+	At             time.Time
+	ReactionsCount int
+}
+
+// ChatReactionSeries returns reactions received by chat messages in reaction buckets of [from, to) grouped by unit
+// "hour" or "day" in loc. Units without reactions are skipped. Zero from returns all time. Buckets are UTC hours, so
+// units of zones with half-hour offsets are shifted by the offset remainder, see ReactionBucketAt.
+func (cr CommonRepo) ChatReactionSeries(ctx context.Context, chatID int64, from, to time.Time, unit string, loc *time.Location) ([]ReactionPoint, error) {
+	var list []ReactionPoint
+	_, err := cr.db.QueryContext(ctx, &list, `
+		SELECT date_trunc(?4, "bucketAt" AT TIME ZONE ?3) AS at, sum("reactionsCount") AS reactions_count
+		FROM "reactionBuckets"
+		WHERE "chatId" = ?0 AND "bucketAt" >= ?1 AND "bucketAt" < ?2
+		GROUP BY 1 ORDER BY 1`,
+		chatID, ReactionBucketAt(from), to, loc.String(), unit)
+	if err != nil {
+		return nil, err
+	}
+
+	// at is local time without zone
+	for i, p := range list {
+		list[i].At = time.Date(p.At.Year(), p.At.Month(), p.At.Day(), p.At.Hour(), 0, 0, 0, loc)
+	}
+
+	return list, nil
+}
+
+// HourReactions is reactions left in chat during an hour of week.
+type HourReactions struct {
+	// Weekday is ISO day of week: 1 is Monday, 7 is Sunday.
+	Weekday        int
+	Hour           int
+	ReactionsCount int
+}
+
+// ChatHourOfWeekReactions returns count of reactions left in chat in [from, to) by weekday and hour in loc.
+// Hours without reactions are skipped. Zero from counts all time.
+func (cr CommonRepo) ChatHourOfWeekReactions(ctx context.Context, chatID int64, from, to time.Time, loc *time.Location) ([]HourReactions, error) {
+	var list []HourReactions
+	_, err := cr.db.QueryContext(ctx, &list, `
+		SELECT extract(isodow FROM h.at)::int AS weekday, extract(hour FROM h.at)::int AS hour, sum(h.n) AS reactions_count
+		FROM (
+			SELECT date_trunc('hour', "createdAt" AT TIME ZONE ?3) AS at, count(*) AS n
+			FROM "reactionEvents"
+			WHERE "chatId" = ?0 AND "isAdded" AND "createdAt" >= ?1 AND "createdAt" < ?2
+			GROUP BY 1
+		) h
+		GROUP BY 1, 2
+		ORDER BY 1, 2`,
+		chatID, from, to, loc.String())
+
+	return list, err
+}
+
+// UserChats returns chats where bot is a member and the user wrote messages or left reactions, ordered by title.
+// User membership should be checked in telegram, the user could leave chat.
+func (cr CommonRepo) UserChats(ctx context.Context, userID int64, pager Pager) ([]Chat, error) {
+	search := &ChatSearch{}
+	search.With(`?.? IS NULL`, pg.Ident(Tables.Chat.Alias), pg.Ident(Columns.Chat.LeftAt))
+	search.With(`(EXISTS (SELECT 1 FROM "messages" m WHERE m."chatId" = ?0."chatId" AND m."userId" = ?1)
+		OR EXISTS (SELECT 1 FROM "reactionEvents" e WHERE e."chatId" = ?0."chatId" AND e."userId" = ?1))`,
+		pg.Ident(Tables.Chat.Alias), userID)
+
+	return cr.ChatsByFilters(ctx, search, pager, WithSort(NewSortField(Columns.Chat.Title, false), NewSortField(Columns.Chat.ID, false)))
+}
@@ -0,0 +1,137 @@
+package db
+
+import (
+	"context"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+)
+
+const (
+	benchChatID = -1009999999999
+	// benchHotMessages is count of messages getting all reactions, like the latest posts of a busy chat.
+	benchHotMessages = 4
+	benchReaction    = "👍"
+)
+
+// benchDB connects to test database from DB_CONN like "postgres://postgres@localhost:5432/reactions?sslmode=disable",
+// benchmarks are skipped without it.
+func benchDB(b *testing.B) *pg.DB {
+	conn := os.Getenv("DB_CONN")
+	if conn == "" {
+		b.Skip("DB_CONN is not set")
+	}
+
+	opts, err := pg.ParseURL(conn)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	dbc := pg.Connect(opts)
+	b.Cleanup(func() { dbc.Close() })
+
+	return dbc
+}
+
+// cleanupBenchChat deletes counters of the benchmark chat.
+func cleanupBenchChat(b *testing.B, dbc *pg.DB) {
+	for _, table := range []string{Tables.MessageReactionCount.Name, Tables.MessageReaction.Name} {
+		if _, err := dbc.Exec(`DELETE FROM ? WHERE "chatId" = ?`, pg.Ident(table), benchChatID); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// selectThenUpsert is the previous counting path: counters are read in transaction, then inserted or updated.
+func selectThenUpsert(ctx context.Context, dbc *pg.DB, messageID int) error {
+	return dbc.RunInTransaction(ctx, func(tx *pg.Tx) error {
+		cr := NewCommonRepo(tx)
+		chatID := int64(benchChatID)
+
+		mr, err := cr.OneMessageReaction(ctx, &MessageReactionSearch{ChatID: &chatID, MessageID: &messageID})
+		if err != nil {
+			return err
+		}
+		if mr == nil {
+			count := 1
+			_, err = cr.AddMessageReaction(ctx, &MessageReaction{ChatID: chatID, MessageID: messageID, ReactionsCount: &count, ReactorsCount: 1})
+		} else {
+			*mr.ReactionsCount++
+			mr.ReactorsCount++
+			_, err = cr.UpdateMessageReaction(ctx, mr)
+		}
+		if err != nil {
+			return err
+		}
+
+		mrc, err := cr.MessageReactionCountByID(ctx, chatID, messageID, "emoji", benchReaction)
+		if err != nil {
+			return err
+		}
+		if mrc == nil {
+			_, err = cr.AddMessageReactionCount(ctx, &MessageReactionCount{ChatID: chatID, MessageID: messageID,
+				ReactionType: "emoji", Reaction: benchReaction, Count: 1, UpdatedAt: time.Now()})
+		} else {
+			mrc.Count++
+			mrc.UpdatedAt = time.Now()
+			_, err = cr.UpdateMessageReactionCount(ctx, mrc)
+		}
+
+		return err
+	})
+}
+
+// increment is the atomic counting path.
+func increment(ctx context.Context, dbc *pg.DB, messageID int) error {
+	cr := NewCommonRepo(dbc)
+	if err := cr.IncrementMessageReaction(ctx, benchChatID, messageID, 1, 1); err != nil {
+		return err
+	}
+
+	return cr.IncrementMessageReactionCount(ctx, benchChatID, messageID, "emoji", benchReaction, 1)
+}
+
+// BenchmarkReactionCounting compares counting paths under parallel reactions to a few hot messages.
+// Besides time it reports failed updates and updates lost by successful calls.
+func BenchmarkReactionCounting(b *testing.B) {
+	dbc := benchDB(b)
+	ctx := context.Background()
+
+	for _, bc := range []struct {
+		name  string
+		count func(ctx context.Context, dbc *pg.DB, messageID int) error
+	}{
+		{name: "select-then-upsert", count: selectThenUpsert},
+		{name: "increment", count: increment},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			cleanupBenchChat(b, dbc)
+			b.Cleanup(func() { cleanupBenchChat(b, dbc) })
+
+			var n, failed atomic.Int64
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					messageID := int(n.Add(1)%benchHotMessages) + 1
+					if err := bc.count(ctx, dbc, messageID); err != nil {
+						failed.Add(1)
+					}
+				}
+			})
+			b.StopTimer()
+
+			var stored int64
+			if _, err := dbc.QueryOne(pg.Scan(&stored), `SELECT coalesce(sum("reactionsCount"), 0) FROM "messageReactions" WHERE "chatId" = ?`,
+				benchChatID); err != nil {
+				b.Fatal(err)
+			}
+
+			succeeded := n.Load() - failed.Load()
+			b.ReportMetric(float64(failed.Load())/float64(b.N), "failed/op")
+			b.ReportMetric(float64(succeeded-stored)/float64(b.N), "lost/op")
+		})
+	}
+}
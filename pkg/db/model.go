@@ -10,16 +10,178 @@ import (
 
 var Columns = struct {
 	MessageReaction struct {
-		ReactionsCount, MessageID, ChatID, CreatedAt string
+		ReactionsCount, MessageID, ChatID, CreatedAt, ReactorsCount, CountsSyncedAt, Score string
+
+		Message string
+	}
+	MessageReactionCount struct {
+		ChatID, MessageID, ReactionType, Reaction, Count, UpdatedAt string
+	}
+	ReactionEvent struct {
+		ID, ChatID, MessageID, UserID, ActorChatID, ReactionType, Reaction, IsAdded, CreatedAt string
+	}
+	Message struct {
+		ChatID, MessageID, ThreadID, UserID, SenderChatID, IsBot, AuthorName, MediaKind, Text, SentAt, CreatedAt string
+	}
+	ForumTopic struct {
+		ChatID, ThreadID, Title, CreatedAt, UpdatedAt string
+	}
+	Chat struct {
+		ID, Type, Title, Username, IsForum, MemberStatus, AdminRights, JoinedAt, LeftAt, CleanupAt, CreatedAt, UpdatedAt string
+	}
+	ReactionWeight struct {
+		ChatID, ReactionType, Reaction, Weight, CreatedAt, UpdatedAt string
+	}
+	ReactionBucket struct {
+		ChatID, MessageID, BucketAt, ReactionsCount string
+	}
+	DigestSchedule struct {
+		ID, ChatID, ThreadID, Cron, Timezone, Period, Sort, QuietFrom, QuietTo, NextRunAt, LastRunAt, CreatedAt string
+	}
+	ChatSetting struct {
+		ChatID, TopN, Period, Sort, Language, Timezone, ExcludedUserIDs, ExcludedThreadIDs, ExcludeBots, ExcludeAnonymous, CreatedAt, UpdatedAt string
+	}
+	User struct {
+		ID, Name, Username, OptedOutAt, CreatedAt, UpdatedAt string
 	}
 }{
 	MessageReaction: struct {
-		ReactionsCount, MessageID, ChatID, CreatedAt string
+		ReactionsCount, MessageID, ChatID, CreatedAt, ReactorsCount, CountsSyncedAt, Score string
+
+		Message string
 	}{
 		ReactionsCount: "reactionsCount",
 		MessageID:      "messageId",
 		ChatID:         "chatId",
 		CreatedAt:      "createdAt",
+		ReactorsCount:  "reactorsCount",
+		CountsSyncedAt: "countsSyncedAt",
+		Score:          "score",
+
+		Message: "Message",
+	},
+	MessageReactionCount: struct {
+		ChatID, MessageID, ReactionType, Reaction, Count, UpdatedAt string
+	}{
+		ChatID:       "chatId",
+		MessageID:    "messageId",
+		ReactionType: "reactionType",
+		Reaction:     "reaction",
+		Count:        "count",
+		UpdatedAt:    "updatedAt",
+	},
+	ReactionEvent: struct {
+		ID, ChatID, MessageID, UserID, ActorChatID, ReactionType, Reaction, IsAdded, CreatedAt string
+	}{
+		ID:           "reactionEventId",
+		ChatID:       "chatId",
+		MessageID:    "messageId",
+		UserID:       "userId",
+		ActorChatID:  "actorChatId",
+		ReactionType: "reactionType",
+		Reaction:     "reaction",
+		IsAdded:      "isAdded",
+		CreatedAt:    "createdAt",
+	},
+	Message: struct {
+		ChatID, MessageID, ThreadID, UserID, SenderChatID, IsBot, AuthorName, MediaKind, Text, SentAt, CreatedAt string
+	}{
+		ChatID:       "chatId",
+		MessageID:    "messageId",
+		ThreadID:     "threadId",
+		UserID:       "userId",
+		SenderChatID: "senderChatId",
+		IsBot:        "isBot",
+		AuthorName:   "authorName",
+		MediaKind:    "mediaKind",
+		Text:         "text",
+		SentAt:       "sentAt",
+		CreatedAt:    "createdAt",
+	},
+	ForumTopic: struct {
+		ChatID, ThreadID, Title, CreatedAt, UpdatedAt string
+	}{
+		ChatID:    "chatId",
+		ThreadID:  "threadId",
+		Title:     "title",
+		CreatedAt: "createdAt",
+		UpdatedAt: "updatedAt",
+	},
+	Chat: struct {
+		ID, Type, Title, Username, IsForum, MemberStatus, AdminRights, JoinedAt, LeftAt, CleanupAt, CreatedAt, UpdatedAt string
+	}{
+		ID:           "chatId",
+		Type:         "type",
+		Title:        "title",
+		Username:     "username",
+		IsForum:      "isForum",
+		MemberStatus: "memberStatus",
+		AdminRights:  "adminRights",
+		JoinedAt:     "joinedAt",
+		LeftAt:       "leftAt",
+		CleanupAt:    "cleanupAt",
+		CreatedAt:    "createdAt",
+		UpdatedAt:    "updatedAt",
+	},
+	ReactionWeight: struct {
+		ChatID, ReactionType, Reaction, Weight, CreatedAt, UpdatedAt string
+	}{
+		ChatID:       "chatId",
+		ReactionType: "reactionType",
+		Reaction:     "reaction",
+		Weight:       "weight",
+		CreatedAt:    "createdAt",
+		UpdatedAt:    "updatedAt",
+	},
+	ReactionBucket: struct {
+		ChatID, MessageID, BucketAt, ReactionsCount string
+	}{
+		ChatID:         "chatId",
+		MessageID:      "messageId",
+		BucketAt:       "bucketAt",
+		ReactionsCount: "reactionsCount",
+	},
+	DigestSchedule: struct {
+		ID, ChatID, ThreadID, Cron, Timezone, Period, Sort, QuietFrom, QuietTo, NextRunAt, LastRunAt, CreatedAt string
+	}{
+		ID:        "digestScheduleId",
+		ChatID:    "chatId",
+		ThreadID:  "threadId",
+		Cron:      "cron",
+		Timezone:  "timezone",
+		Period:    "period",
+		Sort:      "sort",
+		QuietFrom: "quietFrom",
+		QuietTo:   "quietTo",
+		NextRunAt: "nextRunAt",
+		LastRunAt: "lastRunAt",
+		CreatedAt: "createdAt",
+	},
+	ChatSetting: struct {
+		ChatID, TopN, Period, Sort, Language, Timezone, ExcludedUserIDs, ExcludedThreadIDs, ExcludeBots, ExcludeAnonymous, CreatedAt, UpdatedAt string
+	}{
+		ChatID:            "chatId",
+		TopN:              "topN",
+		Period:            "period",
+		Sort:              "sort",
+		Language:          "language",
+		Timezone:          "timezone",
+		ExcludedUserIDs:   "excludedUserIds",
+		ExcludedThreadIDs: "excludedThreadIds",
+		ExcludeBots:       "excludeBots",
+		ExcludeAnonymous:  "excludeAnonymous",
+		CreatedAt:         "createdAt",
+		UpdatedAt:         "updatedAt",
+	},
+	User: struct {
+		ID, Name, Username, OptedOutAt, CreatedAt, UpdatedAt string
+	}{
+		ID:         "userId",
+		Name:       "name",
+		Username:   "username",
+		OptedOutAt: "optedOutAt",
+		CreatedAt:  "createdAt",
+		UpdatedAt:  "updatedAt",
 	},
 }
 
@@ -27,6 +189,36 @@ var Tables = struct {
 	MessageReaction struct {
 		Name, Alias string
 	}
+	MessageReactionCount struct {
+		Name, Alias string
+	}
+	ReactionEvent struct {
+		Name, Alias string
+	}
+	Message struct {
+		Name, Alias string
+	}
+	ForumTopic struct {
+		Name, Alias string
+	}
+	Chat struct {
+		Name, Alias string
+	}
+	ReactionWeight struct {
+		Name, Alias string
+	}
+	ReactionBucket struct {
+		Name, Alias string
+	}
+	DigestSchedule struct {
+		Name, Alias string
+	}
+	ChatSetting struct {
+		Name, Alias string
+	}
+	User struct {
+		Name, Alias string
+	}
 }{
 	MessageReaction: struct {
 		Name, Alias string
@@ -34,13 +226,211 @@ var Tables = struct {
 		Name:  "messageReactions",
 		Alias: "t",
 	},
+	MessageReactionCount: struct {
+		Name, Alias string
+	}{
+		Name:  "messageReactionCounts",
+		Alias: "t",
+	},
+	ReactionEvent: struct {
+		Name, Alias string
+	}{
+		Name:  "reactionEvents",
+		Alias: "t",
+	},
+	Message: struct {
+		Name, Alias string
+	}{
+		Name:  "messages",
+		Alias: "t",
+	},
+	ForumTopic: struct {
+		Name, Alias string
+	}{
+		Name:  "forumTopics",
+		Alias: "t",
+	},
+	Chat: struct {
+		Name, Alias string
+	}{
+		Name:  "chats",
+		Alias: "t",
+	},
+	ReactionWeight: struct {
+		Name, Alias string
+	}{
+		Name:  "reactionWeights",
+		Alias: "t",
+	},
+	ReactionBucket: struct {
+		Name, Alias string
+	}{
+		Name:  "reactionBuckets",
+		Alias: "t",
+	},
+	DigestSchedule: struct {
+		Name, Alias string
+	}{
+		Name:  "digestSchedules",
+		Alias: "t",
+	},
+	ChatSetting: struct {
+		Name, Alias string
+	}{
+		Name:  "chatSettings",
+		Alias: "t",
+	},
+	User: struct {
+		Name, Alias string
+	}{
+		Name:  "users",
+		Alias: "t",
+	},
 }
 
 type MessageReaction struct {
 	tableName struct{} `pg:"messageReactions,alias:t,discard_unknown_columns"`
 
-	ReactionsCount *int      `pg:"reactionsCount"`
-	MessageID      int       `pg:"messageId,pk"`
+	ReactionsCount *int       `pg:"reactionsCount"`
+	MessageID      int        `pg:"messageId,pk"`
+	ChatID         int64      `pg:"chatId,pk"`
+	CreatedAt      time.Time  `pg:"createdAt,use_zero"`
+	ReactorsCount  int        `pg:"reactorsCount,use_zero"`
+	CountsSyncedAt *time.Time `pg:"countsSyncedAt"`
+	Score          float64    `pg:"score,use_zero"`
+
+	Message *Message `pg:"rel:has-one"`
+}
+
+type MessageReactionCount struct {
+	tableName struct{} `pg:"messageReactionCounts,alias:t,discard_unknown_columns"`
+
+	ChatID       int64     `pg:"chatId,pk"`
+	MessageID    int       `pg:"messageId,pk"`
+	ReactionType string    `pg:"reactionType,pk"`
+	Reaction     string    `pg:"reaction,pk"`
+	Count        int       `pg:"count,use_zero"`
+	UpdatedAt    time.Time `pg:"updatedAt,use_zero"`
+}
+
+type ReactionEvent struct {
+	tableName struct{} `pg:"reactionEvents,alias:t,discard_unknown_columns"`
+
+	ID           int       `pg:"reactionEventId,pk"`
+	ChatID       int64     `pg:"chatId,use_zero"`
+	MessageID    int       `pg:"messageId,use_zero"`
+	UserID       *int64    `pg:"userId"`
+	ActorChatID  *int64    `pg:"actorChatId"`
+	ReactionType string    `pg:"reactionType,use_zero"`
+	Reaction     string    `pg:"reaction,use_zero"`
+	IsAdded      bool      `pg:"isAdded,use_zero"`
+	CreatedAt    time.Time `pg:"createdAt,use_zero"`
+}
+
+type Message struct {
+	tableName struct{} `pg:"messages,alias:t,discard_unknown_columns"`
+
+	ChatID       int64     `pg:"chatId,pk"`
+	MessageID    int       `pg:"messageId,pk"`
+	ThreadID     *int      `pg:"threadId"`
+	UserID       *int64    `pg:"userId"`
+	SenderChatID *int64    `pg:"senderChatId"`
+	IsBot        bool      `pg:"isBot,use_zero"`
+	AuthorName   *string   `pg:"authorName"`
+	MediaKind    *string   `pg:"mediaKind"`
+	Text         *string   `pg:"text"`
+	SentAt       time.Time `pg:"sentAt,use_zero"`
+	CreatedAt    time.Time `pg:"createdAt,use_zero"`
+}
+
+type ForumTopic struct {
+	tableName struct{} `pg:"forumTopics,alias:t,discard_unknown_columns"`
+
+	ChatID    int64     `pg:"chatId,pk"`
+	ThreadID  int       `pg:"threadId,pk"`
+	Title     string    `pg:"title,use_zero"`
+	CreatedAt time.Time `pg:"createdAt,use_zero"`
+	UpdatedAt time.Time `pg:"updatedAt,use_zero"`
+}
+
+type Chat struct {
+	tableName struct{} `pg:"chats,alias:t,discard_unknown_columns"`
+
+	ID           int64            `pg:"chatId,pk"`
+	Type         string           `pg:"type,use_zero"`
+	Title        *string          `pg:"title"`
+	Username     *string          `pg:"username"`
+	IsForum      bool             `pg:"isForum,use_zero"`
+	MemberStatus string           `pg:"memberStatus,use_zero"`
+	AdminRights  *ChatAdminRights `pg:"adminRights"`
+	JoinedAt     *time.Time       `pg:"joinedAt"`
+	LeftAt       *time.Time       `pg:"leftAt"`
+	CleanupAt    *time.Time       `pg:"cleanupAt"`
+	CreatedAt    time.Time        `pg:"createdAt,use_zero"`
+	UpdatedAt    time.Time        `pg:"updatedAt,use_zero"`
+}
+
+type ReactionWeight struct {
+	tableName struct{} `pg:"reactionWeights,alias:t,discard_unknown_columns"`
+
+	ChatID       int64     `pg:"chatId,pk"`
+	ReactionType string    `pg:"reactionType,pk"`
+	Reaction     string    `pg:"reaction,pk"`
+	Weight       float64   `pg:"weight,use_zero"`
+	CreatedAt    time.Time `pg:"createdAt,use_zero"`
+	UpdatedAt    time.Time `pg:"updatedAt,use_zero"`
+}
+
+type ReactionBucket struct {
+	tableName struct{} `pg:"reactionBuckets,alias:t,discard_unknown_columns"`
+
 	ChatID         int64     `pg:"chatId,pk"`
-	CreatedAt      time.Time `pg:"createdAt,use_zero"`
+	MessageID      int       `pg:"messageId,pk"`
+	BucketAt       time.Time `pg:"bucketAt,pk"`
+	ReactionsCount int       `pg:"reactionsCount,use_zero"`
+}
+
+type DigestSchedule struct {
+	tableName struct{} `pg:"digestSchedules,alias:t,discard_unknown_columns"`
+
+	ID        int        `pg:"digestScheduleId,pk"`
+	ChatID    int64      `pg:"chatId,use_zero"`
+	ThreadID  *int       `pg:"threadId"`
+	Cron      string     `pg:"cron,use_zero"`
+	Timezone  string     `pg:"timezone,use_zero"`
+	Period    string     `pg:"period,use_zero"`
+	Sort      string     `pg:"sort,use_zero"`
+	QuietFrom *int       `pg:"quietFrom"`
+	QuietTo   *int       `pg:"quietTo"`
+	NextRunAt time.Time  `pg:"nextRunAt,use_zero"`
+	LastRunAt *time.Time `pg:"lastRunAt"`
+	CreatedAt time.Time  `pg:"createdAt,use_zero"`
+}
+
+type ChatSetting struct {
+	tableName struct{} `pg:"chatSettings,alias:t,discard_unknown_columns"`
+
+	ChatID            int64     `pg:"chatId,pk"`
+	TopN              int       `pg:"topN,use_zero"`
+	Period            string    `pg:"period,use_zero"`
+	Sort              string    `pg:"sort,use_zero"`
+	Language          *string   `pg:"language"`
+	Timezone          string    `pg:"timezone,use_zero"`
+	ExcludedUserIDs   []int64   `pg:"excludedUserIds,array"`
+	ExcludedThreadIDs []int     `pg:"excludedThreadIds,array"`
+	ExcludeBots       bool      `pg:"excludeBots,use_zero"`
+	ExcludeAnonymous  bool      `pg:"excludeAnonymous,use_zero"`
+	CreatedAt         time.Time `pg:"createdAt,use_zero"`
+	UpdatedAt         time.Time `pg:"updatedAt,use_zero"`
+}
+
+type User struct {
+	tableName struct{} `pg:"users,alias:t,discard_unknown_columns"`
+
+	ID         int64      `pg:"userId,pk"`
+	Name       string     `pg:"name,use_zero"`
+	Username   *string    `pg:"username"`
+	OptedOutAt *time.Time `pg:"optedOutAt"`
+	CreatedAt  time.Time  `pg:"createdAt,use_zero"`
+	UpdatedAt  time.Time  `pg:"updatedAt,use_zero"`
 }
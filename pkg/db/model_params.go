@@ -1 +1,16 @@
 package db
+
+type ChatAdminRights struct {
+	IsAnonymous         bool `json:"isAnonymous"`
+	CanManageChat       bool `json:"canManageChat"`
+	CanDeleteMessages   bool `json:"canDeleteMessages"`
+	CanManageVideoChats bool `json:"canManageVideoChats"`
+	CanRestrictMembers  bool `json:"canRestrictMembers"`
+	CanPromoteMembers   bool `json:"canPromoteMembers"`
+	CanChangeInfo       bool `json:"canChangeInfo"`
+	CanInviteUsers      bool `json:"canInviteUsers"`
+	CanPostMessages     bool `json:"canPostMessages,omitempty"`
+	CanEditMessages     bool `json:"canEditMessages,omitempty"`
+	CanPinMessages      bool `json:"canPinMessages,omitempty"`
+	CanManageTopics     bool `json:"canManageTopics,omitempty"`
+}
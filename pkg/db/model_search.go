@@ -55,13 +55,17 @@ type Searcher interface {
 type MessageReactionSearch struct {
 	search
 
-	ReactionsCount  *int
-	MessageID       *int
-	ChatID          *int64
-	CreatedAt       *time.Time
-	MessageIDs      []int
-	ChatIDs         []int64
-	ReactionsPeriod *time.Time
+	ReactionsCount   *int
+	MessageID        *int
+	ChatID           *int64
+	CreatedAt        *time.Time
+	ReactorsCount    *int
+	CountsSyncedAt   *time.Time
+	Score            *float64
+	MessageIDs       []int
+	ChatIDs          []int64
+	ReactionsPeriod  *time.Time
+	MinReactorsCount *int
 }
 
 func (mrs *MessageReactionSearch) Apply(query *orm.Query) *orm.Query {
@@ -80,6 +84,15 @@ func (mrs *MessageReactionSearch) Apply(query *orm.Query) *orm.Query {
 	if mrs.CreatedAt != nil {
 		mrs.where(query, Tables.MessageReaction.Alias, Columns.MessageReaction.CreatedAt, mrs.CreatedAt)
 	}
+	if mrs.ReactorsCount != nil {
+		mrs.where(query, Tables.MessageReaction.Alias, Columns.MessageReaction.ReactorsCount, mrs.ReactorsCount)
+	}
+	if mrs.CountsSyncedAt != nil {
+		mrs.where(query, Tables.MessageReaction.Alias, Columns.MessageReaction.CountsSyncedAt, mrs.CountsSyncedAt)
+	}
+	if mrs.Score != nil {
+		mrs.where(query, Tables.MessageReaction.Alias, Columns.MessageReaction.Score, mrs.Score)
+	}
 	if len(mrs.MessageIDs) > 0 {
 		Filter{Columns.MessageReaction.MessageID, mrs.MessageIDs, SearchTypeArray, false}.Apply(query)
 	}
@@ -89,6 +102,9 @@ func (mrs *MessageReactionSearch) Apply(query *orm.Query) *orm.Query {
 	if mrs.ReactionsPeriod != nil {
 		Filter{Columns.MessageReaction.CreatedAt, *mrs.ReactionsPeriod, SearchTypeGE, false}.Apply(query)
 	}
+	if mrs.MinReactorsCount != nil {
+		Filter{Columns.MessageReaction.ReactorsCount, *mrs.MinReactorsCount, SearchTypeGE, false}.Apply(query)
+	}
 
 	mrs.apply(query)
 
@@ -103,3 +119,623 @@ func (mrs *MessageReactionSearch) Q() applier {
 		return mrs.Apply(query), nil
 	}
 }
+
+type MessageReactionCountSearch struct {
+	search
+
+	ChatID       *int64
+	MessageID    *int
+	ReactionType *string
+	Reaction     *string
+	Count        *int
+	UpdatedAt    *time.Time
+	MessageIDs   []int
+}
+
+func (mrcs *MessageReactionCountSearch) Apply(query *orm.Query) *orm.Query {
+	if mrcs == nil {
+		return query
+	}
+	if mrcs.ChatID != nil {
+		mrcs.where(query, Tables.MessageReactionCount.Alias, Columns.MessageReactionCount.ChatID, mrcs.ChatID)
+	}
+	if mrcs.MessageID != nil {
+		mrcs.where(query, Tables.MessageReactionCount.Alias, Columns.MessageReactionCount.MessageID, mrcs.MessageID)
+	}
+	if mrcs.ReactionType != nil {
+		mrcs.where(query, Tables.MessageReactionCount.Alias, Columns.MessageReactionCount.ReactionType, mrcs.ReactionType)
+	}
+	if mrcs.Reaction != nil {
+		mrcs.where(query, Tables.MessageReactionCount.Alias, Columns.MessageReactionCount.Reaction, mrcs.Reaction)
+	}
+	if mrcs.Count != nil {
+		mrcs.where(query, Tables.MessageReactionCount.Alias, Columns.MessageReactionCount.Count, mrcs.Count)
+	}
+	if mrcs.UpdatedAt != nil {
+		mrcs.where(query, Tables.MessageReactionCount.Alias, Columns.MessageReactionCount.UpdatedAt, mrcs.UpdatedAt)
+	}
+	if len(mrcs.MessageIDs) > 0 {
+		Filter{Columns.MessageReactionCount.MessageID, mrcs.MessageIDs, SearchTypeArray, false}.Apply(query)
+	}
+
+	mrcs.apply(query)
+
+	return query
+}
+
+func (mrcs *MessageReactionCountSearch) Q() applier {
+	return func(query *orm.Query) (*orm.Query, error) {
+		if mrcs == nil {
+			return query, nil
+		}
+		return mrcs.Apply(query), nil
+	}
+}
+
+type ReactionEventSearch struct {
+	search
+
+	ID           *int
+	ChatID       *int64
+	MessageID    *int
+	UserID       *int64
+	ActorChatID  *int64
+	ReactionType *string
+	Reaction     *string
+	IsAdded      *bool
+	CreatedAt    *time.Time
+	IDs          []int
+	MessageIDs   []int
+	CreatedFrom  *time.Time
+}
+
+func (res *ReactionEventSearch) Apply(query *orm.Query) *orm.Query {
+	if res == nil {
+		return query
+	}
+	if res.ID != nil {
+		res.where(query, Tables.ReactionEvent.Alias, Columns.ReactionEvent.ID, res.ID)
+	}
+	if res.ChatID != nil {
+		res.where(query, Tables.ReactionEvent.Alias, Columns.ReactionEvent.ChatID, res.ChatID)
+	}
+	if res.MessageID != nil {
+		res.where(query, Tables.ReactionEvent.Alias, Columns.ReactionEvent.MessageID, res.MessageID)
+	}
+	if res.UserID != nil {
+		res.where(query, Tables.ReactionEvent.Alias, Columns.ReactionEvent.UserID, res.UserID)
+	}
+	if res.ActorChatID != nil {
+		res.where(query, Tables.ReactionEvent.Alias, Columns.ReactionEvent.ActorChatID, res.ActorChatID)
+	}
+	if res.ReactionType != nil {
+		res.where(query, Tables.ReactionEvent.Alias, Columns.ReactionEvent.ReactionType, res.ReactionType)
+	}
+	if res.Reaction != nil {
+		res.where(query, Tables.ReactionEvent.Alias, Columns.ReactionEvent.Reaction, res.Reaction)
+	}
+	if res.IsAdded != nil {
+		res.where(query, Tables.ReactionEvent.Alias, Columns.ReactionEvent.IsAdded, res.IsAdded)
+	}
+	if res.CreatedAt != nil {
+		res.where(query, Tables.ReactionEvent.Alias, Columns.ReactionEvent.CreatedAt, res.CreatedAt)
+	}
+	if len(res.IDs) > 0 {
+		Filter{Columns.ReactionEvent.ID, res.IDs, SearchTypeArray, false}.Apply(query)
+	}
+	if len(res.MessageIDs) > 0 {
+		Filter{Columns.ReactionEvent.MessageID, res.MessageIDs, SearchTypeArray, false}.Apply(query)
+	}
+	if res.CreatedFrom != nil {
+		Filter{Columns.ReactionEvent.CreatedAt, *res.CreatedFrom, SearchTypeGE, false}.Apply(query)
+	}
+
+	res.apply(query)
+
+	return query
+}
+
+func (res *ReactionEventSearch) Q() applier {
+	return func(query *orm.Query) (*orm.Query, error) {
+		if res == nil {
+			return query, nil
+		}
+		return res.Apply(query), nil
+	}
+}
+
+type MessageSearch struct {
+	search
+
+	ChatID       *int64
+	MessageID    *int
+	ThreadID     *int
+	UserID       *int64
+	SenderChatID *int64
+	IsBot        *bool
+	AuthorName   *string
+	MediaKind    *string
+	Text         *string
+	SentAt       *time.Time
+	CreatedAt    *time.Time
+	MessageIDs   []int
+	SentFrom     *time.Time
+}
+
+func (ms *MessageSearch) Apply(query *orm.Query) *orm.Query {
+	if ms == nil {
+		return query
+	}
+	if ms.ChatID != nil {
+		ms.where(query, Tables.Message.Alias, Columns.Message.ChatID, ms.ChatID)
+	}
+	if ms.MessageID != nil {
+		ms.where(query, Tables.Message.Alias, Columns.Message.MessageID, ms.MessageID)
+	}
+	if ms.ThreadID != nil {
+		ms.where(query, Tables.Message.Alias, Columns.Message.ThreadID, ms.ThreadID)
+	}
+	if ms.UserID != nil {
+		ms.where(query, Tables.Message.Alias, Columns.Message.UserID, ms.UserID)
+	}
+	if ms.SenderChatID != nil {
+		ms.where(query, Tables.Message.Alias, Columns.Message.SenderChatID, ms.SenderChatID)
+	}
+	if ms.IsBot != nil {
+		ms.where(query, Tables.Message.Alias, Columns.Message.IsBot, ms.IsBot)
+	}
+	if ms.AuthorName != nil {
+		ms.where(query, Tables.Message.Alias, Columns.Message.AuthorName, ms.AuthorName)
+	}
+	if ms.MediaKind != nil {
+		ms.where(query, Tables.Message.Alias, Columns.Message.MediaKind, ms.MediaKind)
+	}
+	if ms.Text != nil {
+		ms.where(query, Tables.Message.Alias, Columns.Message.Text, ms.Text)
+	}
+	if ms.SentAt != nil {
+		ms.where(query, Tables.Message.Alias, Columns.Message.SentAt, ms.SentAt)
+	}
+	if ms.CreatedAt != nil {
+		ms.where(query, Tables.Message.Alias, Columns.Message.CreatedAt, ms.CreatedAt)
+	}
+	if len(ms.MessageIDs) > 0 {
+		Filter{Columns.Message.MessageID, ms.MessageIDs, SearchTypeArray, false}.Apply(query)
+	}
+	if ms.SentFrom != nil {
+		Filter{Columns.Message.SentAt, *ms.SentFrom, SearchTypeGE, false}.Apply(query)
+	}
+
+	ms.apply(query)
+
+	return query
+}
+
+func (ms *MessageSearch) Q() applier {
+	return func(query *orm.Query) (*orm.Query, error) {
+		if ms == nil {
+			return query, nil
+		}
+		return ms.Apply(query), nil
+	}
+}
+
+type ForumTopicSearch struct {
+	search
+
+	ChatID    *int64
+	ThreadID  *int
+	Title     *string
+	CreatedAt *time.Time
+	UpdatedAt *time.Time
+	ThreadIDs []int
+}
+
+func (fts *ForumTopicSearch) Apply(query *orm.Query) *orm.Query {
+	if fts == nil {
+		return query
+	}
+	if fts.ChatID != nil {
+		fts.where(query, Tables.ForumTopic.Alias, Columns.ForumTopic.ChatID, fts.ChatID)
+	}
+	if fts.ThreadID != nil {
+		fts.where(query, Tables.ForumTopic.Alias, Columns.ForumTopic.ThreadID, fts.ThreadID)
+	}
+	if fts.Title != nil {
+		fts.where(query, Tables.ForumTopic.Alias, Columns.ForumTopic.Title, fts.Title)
+	}
+	if fts.CreatedAt != nil {
+		fts.where(query, Tables.ForumTopic.Alias, Columns.ForumTopic.CreatedAt, fts.CreatedAt)
+	}
+	if fts.UpdatedAt != nil {
+		fts.where(query, Tables.ForumTopic.Alias, Columns.ForumTopic.UpdatedAt, fts.UpdatedAt)
+	}
+	if len(fts.ThreadIDs) > 0 {
+		Filter{Columns.ForumTopic.ThreadID, fts.ThreadIDs, SearchTypeArray, false}.Apply(query)
+	}
+
+	fts.apply(query)
+
+	return query
+}
+
+func (fts *ForumTopicSearch) Q() applier {
+	return func(query *orm.Query) (*orm.Query, error) {
+		if fts == nil {
+			return query, nil
+		}
+		return fts.Apply(query), nil
+	}
+}
+
+type ChatSearch struct {
+	search
+
+	ID           *int64
+	Type         *string
+	Title        *string
+	Username     *string
+	IsForum      *bool
+	MemberStatus *string
+	AdminRights  *ChatAdminRights
+	JoinedAt     *time.Time
+	LeftAt       *time.Time
+	CleanupAt    *time.Time
+	CreatedAt    *time.Time
+	UpdatedAt    *time.Time
+	IDs          []int64
+	CleanupTo    *time.Time
+}
+
+func (cs *ChatSearch) Apply(query *orm.Query) *orm.Query {
+	if cs == nil {
+		return query
+	}
+	if cs.ID != nil {
+		cs.where(query, Tables.Chat.Alias, Columns.Chat.ID, cs.ID)
+	}
+	if cs.Type != nil {
+		cs.where(query, Tables.Chat.Alias, Columns.Chat.Type, cs.Type)
+	}
+	if cs.Title != nil {
+		cs.where(query, Tables.Chat.Alias, Columns.Chat.Title, cs.Title)
+	}
+	if cs.Username != nil {
+		cs.where(query, Tables.Chat.Alias, Columns.Chat.Username, cs.Username)
+	}
+	if cs.IsForum != nil {
+		cs.where(query, Tables.Chat.Alias, Columns.Chat.IsForum, cs.IsForum)
+	}
+	if cs.MemberStatus != nil {
+		cs.where(query, Tables.Chat.Alias, Columns.Chat.MemberStatus, cs.MemberStatus)
+	}
+	if cs.AdminRights != nil {
+		cs.where(query, Tables.Chat.Alias, Columns.Chat.AdminRights, cs.AdminRights)
+	}
+	if cs.JoinedAt != nil {
+		cs.where(query, Tables.Chat.Alias, Columns.Chat.JoinedAt, cs.JoinedAt)
+	}
+	if cs.LeftAt != nil {
+		cs.where(query, Tables.Chat.Alias, Columns.Chat.LeftAt, cs.LeftAt)
+	}
+	if cs.CleanupAt != nil {
+		cs.where(query, Tables.Chat.Alias, Columns.Chat.CleanupAt, cs.CleanupAt)
+	}
+	if cs.CreatedAt != nil {
+		cs.where(query, Tables.Chat.Alias, Columns.Chat.CreatedAt, cs.CreatedAt)
+	}
+	if cs.UpdatedAt != nil {
+		cs.where(query, Tables.Chat.Alias, Columns.Chat.UpdatedAt, cs.UpdatedAt)
+	}
+	if len(cs.IDs) > 0 {
+		Filter{Columns.Chat.ID, cs.IDs, SearchTypeArray, false}.Apply(query)
+	}
+	if cs.CleanupTo != nil {
+		Filter{Columns.Chat.CleanupAt, *cs.CleanupTo, SearchTypeLE, false}.Apply(query)
+	}
+
+	cs.apply(query)
+
+	return query
+}
+
+func (cs *ChatSearch) Q() applier {
+	return func(query *orm.Query) (*orm.Query, error) {
+		if cs == nil {
+			return query, nil
+		}
+		return cs.Apply(query), nil
+	}
+}
+
+type ReactionWeightSearch struct {
+	search
+
+	ChatID       *int64
+	ReactionType *string
+	Reaction     *string
+	Weight       *float64
+	CreatedAt    *time.Time
+	UpdatedAt    *time.Time
+}
+
+func (rws *ReactionWeightSearch) Apply(query *orm.Query) *orm.Query {
+	if rws == nil {
+		return query
+	}
+	if rws.ChatID != nil {
+		rws.where(query, Tables.ReactionWeight.Alias, Columns.ReactionWeight.ChatID, rws.ChatID)
+	}
+	if rws.ReactionType != nil {
+		rws.where(query, Tables.ReactionWeight.Alias, Columns.ReactionWeight.ReactionType, rws.ReactionType)
+	}
+	if rws.Reaction != nil {
+		rws.where(query, Tables.ReactionWeight.Alias, Columns.ReactionWeight.Reaction, rws.Reaction)
+	}
+	if rws.Weight != nil {
+		rws.where(query, Tables.ReactionWeight.Alias, Columns.ReactionWeight.Weight, rws.Weight)
+	}
+	if rws.CreatedAt != nil {
+		rws.where(query, Tables.ReactionWeight.Alias, Columns.ReactionWeight.CreatedAt, rws.CreatedAt)
+	}
+	if rws.UpdatedAt != nil {
+		rws.where(query, Tables.ReactionWeight.Alias, Columns.ReactionWeight.UpdatedAt, rws.UpdatedAt)
+	}
+
+	rws.apply(query)
+
+	return query
+}
+
+func (rws *ReactionWeightSearch) Q() applier {
+	return func(query *orm.Query) (*orm.Query, error) {
+		if rws == nil {
+			return query, nil
+		}
+		return rws.Apply(query), nil
+	}
+}
+
+type ReactionBucketSearch struct {
+	search
+
+	ChatID         *int64
+	MessageID      *int
+	BucketAt       *time.Time
+	ReactionsCount *int
+	MessageIDs     []int
+	BucketFrom     *time.Time
+}
+
+func (rbs *ReactionBucketSearch) Apply(query *orm.Query) *orm.Query {
+	if rbs == nil {
+		return query
+	}
+	if rbs.ChatID != nil {
+		rbs.where(query, Tables.ReactionBucket.Alias, Columns.ReactionBucket.ChatID, rbs.ChatID)
+	}
+	if rbs.MessageID != nil {
+		rbs.where(query, Tables.ReactionBucket.Alias, Columns.ReactionBucket.MessageID, rbs.MessageID)
+	}
+	if rbs.BucketAt != nil {
+		rbs.where(query, Tables.ReactionBucket.Alias, Columns.ReactionBucket.BucketAt, rbs.BucketAt)
+	}
+	if rbs.ReactionsCount != nil {
+		rbs.where(query, Tables.ReactionBucket.Alias, Columns.ReactionBucket.ReactionsCount, rbs.ReactionsCount)
+	}
+	if len(rbs.MessageIDs) > 0 {
+		Filter{Columns.ReactionBucket.MessageID, rbs.MessageIDs, SearchTypeArray, false}.Apply(query)
+	}
+	if rbs.BucketFrom != nil {
+		Filter{Columns.ReactionBucket.BucketAt, *rbs.BucketFrom, SearchTypeGE, false}.Apply(query)
+	}
+
+	rbs.apply(query)
+
+	return query
+}
+
+func (rbs *ReactionBucketSearch) Q() applier {
+	return func(query *orm.Query) (*orm.Query, error) {
+		if rbs == nil {
+			return query, nil
+		}
+		return rbs.Apply(query), nil
+	}
+}
+
+type DigestScheduleSearch struct {
+	search
+
+	ID        *int
+	ChatID    *int64
+	ThreadID  *int
+	Cron      *string
+	Timezone  *string
+	Period    *string
+	Sort      *string
+	QuietFrom *int
+	QuietTo   *int
+	NextRunAt *time.Time
+	LastRunAt *time.Time
+	CreatedAt *time.Time
+	IDs       []int
+	NextRunTo *time.Time
+}
+
+func (dss *DigestScheduleSearch) Apply(query *orm.Query) *orm.Query {
+	if dss == nil {
+		return query
+	}
+	if dss.ID != nil {
+		dss.where(query, Tables.DigestSchedule.Alias, Columns.DigestSchedule.ID, dss.ID)
+	}
+	if dss.ChatID != nil {
+		dss.where(query, Tables.DigestSchedule.Alias, Columns.DigestSchedule.ChatID, dss.ChatID)
+	}
+	if dss.ThreadID != nil {
+		dss.where(query, Tables.DigestSchedule.Alias, Columns.DigestSchedule.ThreadID, dss.ThreadID)
+	}
+	if dss.Cron != nil {
+		dss.where(query, Tables.DigestSchedule.Alias, Columns.DigestSchedule.Cron, dss.Cron)
+	}
+	if dss.Timezone != nil {
+		dss.where(query, Tables.DigestSchedule.Alias, Columns.DigestSchedule.Timezone, dss.Timezone)
+	}
+	if dss.Period != nil {
+		dss.where(query, Tables.DigestSchedule.Alias, Columns.DigestSchedule.Period, dss.Period)
+	}
+	if dss.Sort != nil {
+		dss.where(query, Tables.DigestSchedule.Alias, Columns.DigestSchedule.Sort, dss.Sort)
+	}
+	if dss.QuietFrom != nil {
+		dss.where(query, Tables.DigestSchedule.Alias, Columns.DigestSchedule.QuietFrom, dss.QuietFrom)
+	}
+	if dss.QuietTo != nil {
+		dss.where(query, Tables.DigestSchedule.Alias, Columns.DigestSchedule.QuietTo, dss.QuietTo)
+	}
+	if dss.NextRunAt != nil {
+		dss.where(query, Tables.DigestSchedule.Alias, Columns.DigestSchedule.NextRunAt, dss.NextRunAt)
+	}
+	if dss.LastRunAt != nil {
+		dss.where(query, Tables.DigestSchedule.Alias, Columns.DigestSchedule.LastRunAt, dss.LastRunAt)
+	}
+	if dss.CreatedAt != nil {
+		dss.where(query, Tables.DigestSchedule.Alias, Columns.DigestSchedule.CreatedAt, dss.CreatedAt)
+	}
+	if len(dss.IDs) > 0 {
+		Filter{Columns.DigestSchedule.ID, dss.IDs, SearchTypeArray, false}.Apply(query)
+	}
+	if dss.NextRunTo != nil {
+		Filter{Columns.DigestSchedule.NextRunAt, *dss.NextRunTo, SearchTypeLE, false}.Apply(query)
+	}
+
+	dss.apply(query)
+
+	return query
+}
+
+func (dss *DigestScheduleSearch) Q() applier {
+	return func(query *orm.Query) (*orm.Query, error) {
+		if dss == nil {
+			return query, nil
+		}
+		return dss.Apply(query), nil
+	}
+}
+
+type ChatSettingSearch struct {
+	search
+
+	ChatID           *int64
+	TopN             *int
+	Period           *string
+	Sort             *string
+	Language         *string
+	Timezone         *string
+	ExcludeBots      *bool
+	ExcludeAnonymous *bool
+	CreatedAt        *time.Time
+	UpdatedAt        *time.Time
+}
+
+func (css *ChatSettingSearch) Apply(query *orm.Query) *orm.Query {
+	if css == nil {
+		return query
+	}
+	if css.ChatID != nil {
+		css.where(query, Tables.ChatSetting.Alias, Columns.ChatSetting.ChatID, css.ChatID)
+	}
+	if css.TopN != nil {
+		css.where(query, Tables.ChatSetting.Alias, Columns.ChatSetting.TopN, css.TopN)
+	}
+	if css.Period != nil {
+		css.where(query, Tables.ChatSetting.Alias, Columns.ChatSetting.Period, css.Period)
+	}
+	if css.Sort != nil {
+		css.where(query, Tables.ChatSetting.Alias, Columns.ChatSetting.Sort, css.Sort)
+	}
+	if css.Language != nil {
+		css.where(query, Tables.ChatSetting.Alias, Columns.ChatSetting.Language, css.Language)
+	}
+	if css.Timezone != nil {
+		css.where(query, Tables.ChatSetting.Alias, Columns.ChatSetting.Timezone, css.Timezone)
+	}
+	if css.ExcludeBots != nil {
+		css.where(query, Tables.ChatSetting.Alias, Columns.ChatSetting.ExcludeBots, css.ExcludeBots)
+	}
+	if css.ExcludeAnonymous != nil {
+		css.where(query, Tables.ChatSetting.Alias, Columns.ChatSetting.ExcludeAnonymous, css.ExcludeAnonymous)
+	}
+	if css.CreatedAt != nil {
+		css.where(query, Tables.ChatSetting.Alias, Columns.ChatSetting.CreatedAt, css.CreatedAt)
+	}
+	if css.UpdatedAt != nil {
+		css.where(query, Tables.ChatSetting.Alias, Columns.ChatSetting.UpdatedAt, css.UpdatedAt)
+	}
+
+	css.apply(query)
+
+	return query
+}
+
+func (css *ChatSettingSearch) Q() applier {
+	return func(query *orm.Query) (*orm.Query, error) {
+		if css == nil {
+			return query, nil
+		}
+		return css.Apply(query), nil
+	}
+}
+
+type UserSearch struct {
+	search
+
+	ID         *int64
+	Name       *string
+	Username   *string
+	OptedOutAt *time.Time
+	CreatedAt  *time.Time
+	UpdatedAt  *time.Time
+	IDs        []int64
+}
+
+func (us *UserSearch) Apply(query *orm.Query) *orm.Query {
+	if us == nil {
+		return query
+	}
+	if us.ID != nil {
+		us.where(query, Tables.User.Alias, Columns.User.ID, us.ID)
+	}
+	if us.Name != nil {
+		us.where(query, Tables.User.Alias, Columns.User.Name, us.Name)
+	}
+	if us.Username != nil {
+		us.where(query, Tables.User.Alias, Columns.User.Username, us.Username)
+	}
+	if us.OptedOutAt != nil {
+		us.where(query, Tables.User.Alias, Columns.User.OptedOutAt, us.OptedOutAt)
+	}
+	if us.CreatedAt != nil {
+		us.where(query, Tables.User.Alias, Columns.User.CreatedAt, us.CreatedAt)
+	}
+	if us.UpdatedAt != nil {
+		us.where(query, Tables.User.Alias, Columns.User.UpdatedAt, us.UpdatedAt)
+	}
+	if len(us.IDs) > 0 {
+		Filter{Columns.User.ID, us.IDs, SearchTypeArray, false}.Apply(query)
+	}
+
+	us.apply(query)
+
+	return query
+}
+
+func (us *UserSearch) Q() applier {
+	return func(query *orm.Query) (*orm.Query, error) {
+		if us == nil {
+			return query, nil
+		}
+		return us.Apply(query), nil
+	}
+}